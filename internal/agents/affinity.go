@@ -0,0 +1,47 @@
+package agents
+
+// Content-based routing on payload attributes
+
+import "github.com/ngx-workshop/mcp-server/internal/tasks"
+
+// PayloadMatcher is an optional Agent extension for agents specialized by
+// payload attribute, e.g. a grader for one language. Agents without it take
+// any payload of the types they handle. Implementations must be cheap and must
+// not call back into the registry: they run under its lock.
+type PayloadMatcher interface {
+	CanHandlePayload(taskType string, payload map[string]any) bool
+}
+
+// handlesPayload reports whether a accepts payload for taskType.
+func handlesPayload(a Agent, taskType string, payload map[string]any) bool {
+	m, ok := As[PayloadMatcher](a)
+	return !ok || m.CanHandlePayload(taskType, payload)
+}
+
+// SelectTask is Select for a concrete task: candidates must handle both its
+// type and, if they implement PayloadMatcher, its payload. Round-robin state
+// is kept apart from Select's since the filtered candidate set differs.
+func (r *Registry) SelectTask(t tasks.Task) (Agent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.candidates(t.Type)
+	if len(list) == 0 {
+		return r.selected(t.Type, nil)
+	}
+	r.byType[t.Type] = list
+
+	matching := make([]Agent, 0, len(list))
+	for _, a := range list {
+		if handlesPayload(a, t.Type, t.Payload) {
+			matching = append(matching, a)
+		}
+	}
+	key := t.Type + "/payload"
+	i, _ := r.pick(t.Type, matching, r.rrIdx[key], nil)
+	if i < 0 {
+		return r.selected(t.Type, nil)
+	}
+	r.advance(key, matching, i)
+	return r.selected(t.Type, matching[i])
+}
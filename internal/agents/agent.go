@@ -1,6 +1,9 @@
 package agents
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Task struct {
 	ID         string
@@ -15,8 +18,51 @@ type Result struct {
 	Error  string
 }
 
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusPartial = "partial"
+)
+
+// RemainingKey is the Output key under which a partial result carries the
+// payload for the work it did not get to. See Agent for the convention.
+const RemainingKey = "_remaining"
+
+// Agent executes tasks of the types it can handle.
+//
+// Partial results: an agent that runs out of time should not throw away what
+// it already did. Check ctx.Done() between units of work and, when it fires,
+// return Result{Status: StatusPartial} with the completed work in Output and,
+// if the rest can be resumed, the payload for the remaining work under
+// Output[RemainingKey]. Returning ctx.Err() alongside is fine; the
+// orchestrator honors the partial status either way, stores it, and may
+// re-dispatch only the remaining payload.
+//
+// Side effects: attempts of one task may repeat. Agents calling external APIs
+// should forward IdempotencyKey(ctx) to them; see its contract.
 type Agent interface {
 	Name() string
 	CanHandle(taskType string) bool
 	Execute(ctx context.Context, t Task) (Result, error)
 }
+
+// Remaining extracts the resumable payload from a partial result's Output.
+func Remaining(out map[string]any) (map[string]any, bool) {
+	rest, ok := out[RemainingKey].(map[string]any)
+	return rest, ok && len(rest) > 0
+}
+
+// DurationEstimator is an optional Agent extension. An agent that knows it
+// needs at least EstimateDuration(t) for a task is skipped, in favor of
+// another agent or a fast failure, when less time than that is left before
+// the execution's deadline.
+type DurationEstimator interface {
+	EstimateDuration(t Task) time.Duration
+}
+
+// CostEstimator is an optional Agent extension declaring what one execution
+// of a task is expected to cost, in whatever unit the run budget uses (e.g.
+// US cents of API spend). Agents without it are free.
+type CostEstimator interface {
+	EstimateCost(t Task) float64
+}
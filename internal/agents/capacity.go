@@ -0,0 +1,107 @@
+package agents
+
+// Capacity accounting: per-agent and per-(agent, task type) in-flight counts
+//
+// Capacity bounds an agent's total concurrent executions. MaxTypeShare
+// subdivides it: with Capacity 10 and MaxTypeShare 0.6 no task type may hold
+// more than 6 slots, leaving at least 4 for the agent's other types. Both
+// limits are enforced by AcquireFor and honored by Select, which treats an
+// agent that is full overall or for the requested type as at capacity.
+
+import (
+	"math"
+	"time"
+)
+
+// typeLimit is the most slots one task type may hold; 0 means no per-type cap.
+func (st *agentState) typeLimit() int {
+	if st.opts.Capacity <= 0 || st.opts.MaxTypeShare <= 0 || st.opts.MaxTypeShare >= 1 {
+		return 0
+	}
+	return max(1, int(math.Floor(st.opts.MaxTypeShare*float64(st.opts.Capacity))))
+}
+
+// full reports whether another taskType execution would exceed a limit.
+// An empty taskType checks only the overall capacity.
+func (st *agentState) full(taskType string) bool {
+	if st.opts.Capacity > 0 && st.inflight >= st.opts.Capacity {
+		return true
+	}
+	if lim := st.typeLimit(); lim > 0 && taskType != "" && st.byType[taskType] >= lim {
+		return true
+	}
+	return false
+}
+
+// Acquire reserves one unit of the agent's capacity before executing a task.
+// It returns false if the agent is unknown or already at capacity. Prefer
+// AcquireFor when the task type is known so MaxTypeShare is enforced.
+func (r *Registry) Acquire(name string) bool {
+	return r.AcquireFor(name, "")
+}
+
+// Release returns capacity reserved by Acquire.
+func (r *Registry) Release(name string) {
+	r.ReleaseFor(name, "")
+}
+
+// AcquireFor reserves a slot on the agent for one execution of taskType,
+// failing if the agent is unknown, at capacity, taskType already holds its
+// MaxTypeShare of the capacity, or the agent has used up its Rate. A
+// successful call spends one unit of the Rate, which Release does not
+// return.
+func (r *Registry) AcquireFor(name, taskType string) bool {
+	name = r.canonical(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.state[name]
+	now := time.Now()
+	if !ok || st.full(taskType) || st.limited(now) {
+		return false
+	}
+	st.take(now)
+	st.inflight++
+	if taskType != "" {
+		if st.byType == nil {
+			st.byType = make(map[string]int)
+		}
+		st.byType[taskType]++
+	}
+	return true
+}
+
+// ReleaseFor returns a slot reserved by AcquireFor with the same task type.
+func (r *Registry) ReleaseFor(name, taskType string) {
+	name = r.canonical(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.state[name]
+	if !ok {
+		return
+	}
+	if st.inflight > 0 {
+		st.inflight--
+	}
+	if n := st.byType[taskType]; n > 1 {
+		st.byType[taskType] = n - 1
+	} else {
+		delete(st.byType, taskType)
+	}
+	r.notify()
+}
+
+// InFlight returns the agent's current in-flight count, total and per type.
+func (r *Registry) InFlight(name string) (total int, byType map[string]int) {
+	name = r.canonical(name)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st, ok := r.state[name]
+	if !ok {
+		return 0, nil
+	}
+	byType = make(map[string]int, len(st.byType))
+	for t, n := range st.byType {
+		byType[t] = n
+	}
+	return st.inflight, byType
+}
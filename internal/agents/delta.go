@@ -0,0 +1,30 @@
+package agents
+
+// Delta-emitting agents
+// Long jobs that build their output step by step (e.g. grading question by
+// question) hand out each step's output as a delta. The orchestrator merges
+// the deltas and checkpoints the running output, so a crashed job resumes
+// from its last checkpoint instead of from scratch.
+
+import "context"
+
+// CheckpointKey is the payload key under which a resumed execution finds the
+// output checkpointed by an earlier attempt of the same task.
+const CheckpointKey = "_checkpoint"
+
+// DeltaAgent is an Agent whose output grows incrementally. ExecuteDeltas
+// calls emit with every delta, an Output fragment, in order, and returns the
+// final result; its Output, if any, is merged over the deltas as the last
+// one, so it need not repeat them. A resumed execution finds the output so
+// far in the payload (see Checkpoint) and should skip the work it covers.
+type DeltaAgent interface {
+	Agent
+	ExecuteDeltas(ctx context.Context, t Task, emit func(delta map[string]any)) (Result, error)
+}
+
+// Checkpoint extracts the output an earlier attempt got to from a resumed
+// task's payload.
+func Checkpoint(payload map[string]any) (map[string]any, bool) {
+	out, ok := payload[CheckpointKey].(map[string]any)
+	return out, ok && len(out) > 0
+}
@@ -0,0 +1,108 @@
+package agents
+
+// Agent self-description for operators and the service catalog
+
+import "sort"
+
+// AgentDescription is what an agent reports about itself.
+type AgentDescription struct {
+	Name         string
+	Namespace    string
+	TaskTypes    []string
+	Version      string
+	Capacity     int            // concurrent executions; zero means unlimited
+	Capabilities map[string]any // free-form, e.g. model name or languages
+
+	// Replicas names the physical agents of a replica group; set only on
+	// the group's combined entry in Descriptions.
+	Replicas []string
+}
+
+// Describer is an optional Agent extension for self-description.
+type Describer interface {
+	Describe() AgentDescription
+}
+
+// Description returns the description of the named agent. The registry fills
+// in what the agent leaves empty: its name (qualified, also when the agent
+// reports its short one), the task types it is indexed under and the
+// capacity it was registered with, and always sets Namespace. Agents that do
+// not implement Describer get only those.
+func (r *Registry) Description(name string) (AgentDescription, bool) {
+	name = r.canonical(name)
+	r.mu.RLock()
+	a, ok := r.byName[name]
+	var capacity int
+	if st := r.state[name]; st != nil {
+		capacity = st.opts.Capacity
+	}
+	r.mu.RUnlock()
+	if !ok {
+		return AgentDescription{}, false
+	}
+
+	var d AgentDescription
+	if ds, ok := As[Describer](a); ok {
+		d = ds.Describe()
+	}
+	if _, short := SplitName(name); d.Name == "" || d.Name == short {
+		d.Name = name
+	}
+	d.Namespace, _ = r.Namespace(name)
+	if len(d.TaskTypes) == 0 {
+		d.TaskTypes = r.TaskTypes(name)
+	}
+	if d.Capacity == 0 {
+		d.Capacity = capacity
+	}
+	return d, true
+}
+
+// Descriptions describes every registered agent, sorted by name. Replicas
+// of a group are reported as one logical agent named after the group (see
+// groupDescription).
+func (r *Registry) Descriptions() []AgentDescription {
+	list := r.List()
+	out := make([]AgentDescription, 0, len(list))
+	groups := make(map[string]int) // group -> index in out
+	for _, a := range list {
+		d, ok := r.Description(a.Name())
+		if !ok {
+			continue
+		}
+		g, grouped := r.Group(a.Name())
+		if !grouped {
+			out = append(out, d)
+			continue
+		}
+		if i, seen := groups[g]; seen {
+			out[i] = groupDescription(out[i], d)
+			continue
+		}
+		groups[g] = len(out)
+		d.Name, d.Replicas = g, []string{d.Name}
+		d.TaskTypes = append([]string(nil), d.TaskTypes...) // merged into below
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// groupDescription folds replica d into its group's entry: task types are
+// merged, capacities add up (unlimited if any replica is) and version and
+// capabilities come from the first replica.
+func groupDescription(g, d AgentDescription) AgentDescription {
+	g.Replicas = append(g.Replicas, d.Name)
+	for _, t := range d.TaskTypes {
+		if !contains(g.TaskTypes, t) {
+			g.TaskTypes = append(g.TaskTypes, t)
+		}
+	}
+	sort.Strings(g.TaskTypes)
+	if g.Capacity == 0 || d.Capacity == 0 {
+		g.Capacity = 0
+	} else {
+		g.Capacity += d.Capacity
+	}
+	return g
+}
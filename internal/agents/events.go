@@ -0,0 +1,142 @@
+package agents
+
+// Registry event stream for live dashboards
+
+import (
+	"sync"
+	"time"
+)
+
+type RegistryEventKind string
+
+const (
+	EventRegistered    RegistryEventKind = "registered"
+	EventDeregistered  RegistryEventKind = "deregistered"
+	EventHealthChanged RegistryEventKind = "health_changed"
+)
+
+// RegistryEvent is one change to the registry.
+type RegistryEvent struct {
+	Kind    RegistryEventKind `json:"kind"`
+	Agent   string            `json:"agent"`
+	Healthy bool              `json:"healthy"` // state after the change; false after deregistration
+	At      time.Time         `json:"at"`
+}
+
+// SlowSubscriberPolicy decides what happens when a subscriber's buffer is full.
+type SlowSubscriberPolicy int
+
+const (
+	// DropEvents discards the event for that subscriber. Registration never waits.
+	DropEvents SlowSubscriberPolicy = iota
+	// BlockEvents waits for the subscriber up to the block timeout, then
+	// drops. Registration calls wait with it, but never hold the registry
+	// lock while they do.
+	BlockEvents
+)
+
+const (
+	DefaultEventBuffer       = 64
+	DefaultEventBlockTimeout = time.Second
+)
+
+// RegistryOption configures a Registry at construction.
+type RegistryOption func(*Registry)
+
+// WithEventBuffer sets the channel capacity of each subscription.
+func WithEventBuffer(n int) RegistryOption {
+	return func(r *Registry) { r.events.buffer = n }
+}
+
+// WithSlowSubscriberPolicy sets how full subscriptions are handled. timeout
+// bounds the wait under BlockEvents; zero means DefaultEventBlockTimeout.
+func WithSlowSubscriberPolicy(p SlowSubscriberPolicy, timeout time.Duration) RegistryOption {
+	return func(r *Registry) { r.events.policy, r.events.timeout = p, timeout }
+}
+
+// eventHub fans registry events out to subscribers. Events are queued under
+// r.mu and delivered after it is released, in order.
+type eventHub struct {
+	buffer  int
+	policy  SlowSubscriberPolicy
+	timeout time.Duration
+
+	outbox []RegistryEvent // guarded by r.mu
+
+	mu   sync.Mutex // serializes delivery and guards subs
+	subs map[chan RegistryEvent]struct{}
+}
+
+// Subscribe returns a channel of registry events and a function that ends
+// the subscription and closes the channel. Events that do not fit the
+// channel's buffer are handled per the registry's SlowSubscriberPolicy.
+func (r *Registry) Subscribe() (<-chan RegistryEvent, func()) {
+	h := &r.events
+	n := h.buffer
+	if n <= 0 {
+		n = DefaultEventBuffer
+	}
+	ch := make(chan RegistryEvent, n)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan RegistryEvent]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subs, ch)
+			close(ch)
+		})
+	}
+}
+
+// emit queues an event for delivery by flushEvents. Caller must hold r.mu
+// for writing.
+func (r *Registry) emit(kind RegistryEventKind, name string, healthy bool) {
+	r.events.outbox = append(r.events.outbox, RegistryEvent{Kind: kind, Agent: name, Healthy: healthy, At: time.Now()})
+}
+
+// flushEvents delivers queued events. Call it without holding r.mu, e.g. by
+// deferring it before the deferred unlock.
+func (r *Registry) flushEvents() {
+	h := &r.events
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r.mu.Lock()
+	evs := h.outbox
+	h.outbox = nil
+	r.mu.Unlock()
+
+	for _, e := range evs {
+		for ch := range h.subs {
+			h.deliver(ch, e)
+		}
+	}
+}
+
+func (h *eventHub) deliver(ch chan RegistryEvent, e RegistryEvent) {
+	select {
+	case ch <- e:
+		return
+	default:
+	}
+	if h.policy != BlockEvents {
+		return
+	}
+	timeout := h.timeout
+	if timeout <= 0 {
+		timeout = DefaultEventBlockTimeout
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case ch <- e:
+	case <-t.C:
+	}
+}
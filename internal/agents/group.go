@@ -0,0 +1,58 @@
+package agents
+
+// Replica groups: several physical agents behind one logical name
+// Replicas are registered under their own names, so each is health-checked,
+// capacity-tracked and selected individually; registering them for the same
+// task types makes them share round-robin routing. The group name ties them
+// together for introspection and bulk deregistration.
+
+import "sort"
+
+// Group returns the group a registered agent belongs to, if any.
+func (r *Registry) Group(name string) (string, bool) {
+	name = r.canonical(name)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st, ok := r.state[name]
+	if !ok || st.opts.Group == "" {
+		return "", false
+	}
+	return st.opts.Group, true
+}
+
+// Replicas returns the agents registered with RegisterOptions.Group set to
+// group, sorted by name.
+func (r *Registry) Replicas(group string) []Agent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.replicas(group)
+}
+
+// replicas is Replicas without locking. Caller must hold r.mu.
+func (r *Registry) replicas(group string) []Agent {
+	var out []Agent
+	if group == "" {
+		return nil
+	}
+	for name, st := range r.state {
+		if st.opts.Group == group {
+			out = append(out, r.byName[name])
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// DeregisterGroup removes every replica of group and returns how many there
+// were.
+func (r *Registry) DeregisterGroup(group string) int {
+	defer r.flushEvents()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.replicas(group)
+	for _, a := range list {
+		r.remove(a.Name())
+	}
+	return len(list)
+}
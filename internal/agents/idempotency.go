@@ -0,0 +1,38 @@
+package agents
+
+// Idempotency keys for agents that call external APIs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns ctx carrying key. The orchestrator calls it
+// before every execution attempt.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key of the task being executed.
+//
+// Contract for agent authors: the key is derived from the task ID, so it is
+// the same for every attempt of a task: orchestrator retries, redeliveries
+// after a crash and re-dispatches of a partial result's remaining work.
+// Forward it unchanged (e.g. as an Idempotency-Key header) to external APIs
+// with side effects so a repeated attempt cannot repeat the effect. An agent
+// that makes several distinct calls per task, or resumes with a different
+// remaining payload, must derive one key per call, e.g. key + "/" + itemID;
+// otherwise the remote side may mistake a new call for a duplicate.
+func IdempotencyKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// IdempotencyKeyFor derives the stable key for a task ID.
+func IdempotencyKeyFor(taskID string) string {
+	sum := sha256.Sum256([]byte("mcp-task:" + taskID))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,43 @@
+package agents
+
+// Capability matrix for generated docs and coverage checks
+
+import "sort"
+
+// Matrix is the registry's capability matrix in both directions. Lists are
+// sorted, so marshaling the same registry state always yields the same JSON.
+type Matrix struct {
+	ByType  map[string][]string `json:"byType"`  // task type -> agents indexed for it
+	ByAgent map[string][]string `json:"byAgent"` // agent name -> task types it is indexed for
+}
+
+// CapabilityMatrix returns a snapshot of which agents are indexed for which
+// task types, taken under the read lock. Every registered agent appears in
+// ByAgent, with an empty list if it is indexed for no type. Health, capacity
+// and rate limits are not considered: the matrix is what could be routed,
+// not what is routable right now.
+func (r *Registry) CapabilityMatrix() Matrix {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m := Matrix{
+		ByType:  make(map[string][]string, len(r.byType)),
+		ByAgent: make(map[string][]string, len(r.byName)),
+	}
+	for name := range r.byName {
+		m.ByAgent[name] = []string{}
+	}
+	for t, list := range r.byType {
+		names := make([]string, len(list))
+		for i, a := range list {
+			names[i] = a.Name()
+			m.ByAgent[names[i]] = append(m.ByAgent[names[i]], t)
+		}
+		sort.Strings(names)
+		m.ByType[t] = names
+	}
+	for _, types := range m.ByAgent {
+		sort.Strings(types)
+	}
+	return m
+}
@@ -0,0 +1,160 @@
+package agents
+
+// Agent namespaces: "team-a/grader" and "team-b/grader" can coexist
+// Agents in the default namespace keep their bare names, so registries that
+// never use namespaces see no difference.
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultNamespace is the namespace of agents registered without one, unless
+// WithDefaultNamespace says otherwise.
+const DefaultNamespace = "default"
+
+// NamespaceSeparator joins a namespace and an agent's short name.
+const NamespaceSeparator = "/"
+
+// WithDefaultNamespace names the namespace agents without one belong to.
+func WithDefaultNamespace(ns string) RegistryOption {
+	return func(r *Registry) { r.defaultNS = ns }
+}
+
+// QualifiedName returns the fully-qualified name of agent name in namespace.
+func QualifiedName(namespace, name string) string {
+	return namespace + NamespaceSeparator + name
+}
+
+// SplitName splits a fully-qualified name into namespace and short name. A
+// bare name has an empty namespace.
+func SplitName(name string) (namespace, short string) {
+	if i := strings.LastIndex(name, NamespaceSeparator); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// Unwrapper is implemented by agents that wrap another agent, such as the
+// ones a Registry renames into a namespace.
+type Unwrapper interface {
+	Unwrap() Agent
+}
+
+// As reports whether a, or an agent it wraps (see Unwrapper), implements T,
+// and returns the first that does. Check optional agent interfaces with it
+// rather than a type assertion, so wrappers do not hide them.
+func As[T any](a Agent) (T, bool) {
+	for a != nil {
+		if t, ok := a.(T); ok {
+			return t, true
+		}
+		u, ok := a.(Unwrapper)
+		if !ok {
+			break
+		}
+		a = u.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// namespaced gives an agent its fully-qualified name.
+type namespaced struct {
+	Agent
+	name string
+}
+
+func (n namespaced) Name() string  { return n.name }
+func (n namespaced) Unwrap() Agent { return n.Agent }
+
+func (r *Registry) defaultNamespace() string {
+	if r.defaultNS == "" {
+		return DefaultNamespace
+	}
+	return r.defaultNS
+}
+
+// canonical returns the name an agent is registered under: bare for the
+// default namespace, qualified otherwise.
+func (r *Registry) canonical(name string) string {
+	if ns, short := SplitName(name); ns == r.defaultNamespace() {
+		return short
+	}
+	return name
+}
+
+// canonicalAll is canonical for each of names, copying names only if one
+// changes.
+func (r *Registry) canonicalAll(names []string) []string {
+	var out []string
+	for i, n := range names {
+		c := r.canonical(n)
+		if c != n && out == nil {
+			out = slices.Clone(names)
+		}
+		if out != nil {
+			out[i] = c
+		}
+	}
+	if out == nil {
+		return names
+	}
+	return out
+}
+
+// qualify returns a under its registered name. A Name() with a namespace
+// keeps it; namespace, when set, must then agree.
+func (r *Registry) qualify(a Agent, namespace string) (Agent, error) {
+	ns, short := SplitName(a.Name())
+	switch {
+	case short == "":
+		return nil, fmt.Errorf("agent %q has an empty short name", a.Name())
+	case ns == "":
+		ns = namespace
+	case namespace != "" && namespace != ns:
+		return nil, fmt.Errorf("agent %q registered in namespace %q", a.Name(), namespace)
+	}
+	if ns == "" {
+		ns = r.defaultNamespace()
+	}
+	name := r.canonical(QualifiedName(ns, short))
+	if name == a.Name() {
+		return a, nil
+	}
+	return namespaced{Agent: a, name: name}, nil
+}
+
+// Namespace returns the namespace of the named agent.
+func (r *Registry) Namespace(name string) (string, bool) {
+	name = r.canonical(name)
+	r.mu.RLock()
+	_, ok := r.byName[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	if ns, _ := SplitName(name); ns != "" {
+		return ns, true
+	}
+	return r.defaultNamespace(), true
+}
+
+// Namespaces groups the registered agents by namespace, each list sorted by
+// name.
+func (r *Registry) Namespaces() map[string][]Agent {
+	out := make(map[string][]Agent)
+	for _, a := range r.List() {
+		ns, _ := SplitName(a.Name())
+		if ns == "" {
+			ns = r.defaultNamespace()
+		}
+		out[ns] = append(out[ns], a)
+	}
+	for _, list := range out {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	}
+	return out
+}
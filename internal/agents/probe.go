@@ -0,0 +1,75 @@
+package agents
+
+// Registration-time capability probes
+// Remote agents confirm the task types they are registered for before they
+// go live, so a misconfigured agent is caught at registration rather than at
+// its first dispatch.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultProbeTimeout bounds a capability probe when
+// RegisterOptions.ProbeTimeout is zero.
+const DefaultProbeTimeout = 5 * time.Second
+
+var ErrProbeFailed = errors.New("agent capability probe failed")
+
+// CapabilityProber is an optional Agent extension, meant for agents fronting
+// a remote service: ProbeCapabilities asks the service, e.g. through its
+// capability or health endpoint, which task types it serves.
+type CapabilityProber interface {
+	ProbeCapabilities(ctx context.Context) ([]string, error)
+}
+
+// ProbeMode says what registration does with a CapabilityProber.
+type ProbeMode int
+
+const (
+	ProbeOff         ProbeMode = iota // register without probing
+	ProbeRequire                      // fail registration unless the probe confirms every task type
+	ProbeMarkUnready                  // register anyway, but unhealthy until SetHealthy
+)
+
+// probe runs a's capability probe, if it has one, and reports an error
+// unless it confirms every one of taskTypes that a.CanHandle, which are the
+// ones insert indexes. With none of those (no types given, or none a can
+// handle) there is nothing the probe could confirm, and that is an error
+// too. It must not run under r.mu.
+func probe(a Agent, taskTypes []string, timeout time.Duration) error {
+	p, ok := As[CapabilityProber](a)
+	if !ok {
+		return nil // a local agent; there is no service to ask
+	}
+	var claimed []string
+	for _, t := range dedupe(taskTypes) {
+		if a.CanHandle(t) {
+			claimed = append(claimed, t)
+		}
+	}
+	if len(claimed) == 0 {
+		return fmt.Errorf("%w: %s: no task types to confirm", ErrProbeFailed, a.Name())
+	}
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	served, err := p.ProbeCapabilities(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrProbeFailed, a.Name(), err)
+	}
+	var missing []string
+	for _, t := range claimed {
+		if !contains(served, t) {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s does not serve %v", ErrProbeFailed, a.Name(), missing)
+	}
+	return nil
+}
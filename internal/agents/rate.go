@@ -0,0 +1,106 @@
+package agents
+
+// Per-agent invocation rate limits
+//
+// Rate is separate from Capacity: an idle agent in front of an external API
+// with a per-minute quota may still have to be skipped. Each agent with a
+// Rate has a token bucket holding up to N tokens that refills continuously
+// at N per Per. Select skips agents with less than one token; AcquireFor
+// takes one, so the bucket counts invocations the orchestrator started.
+
+import (
+	"sync"
+	"time"
+)
+
+// Rate caps invocations at N per Per. The zero value means unlimited.
+type Rate struct {
+	N   int
+	Per time.Duration
+}
+
+func (r Rate) enabled() bool { return r.N > 0 && r.Per > 0 }
+
+// bucket is an agent's token bucket; the zero value is full.
+type bucket struct {
+	used bool // false until the first token is taken
+	tok  float64
+	last time.Time
+}
+
+// tokens returns how many tokens st's bucket holds at now, refilled but not
+// stored.
+func (st *agentState) tokens(now time.Time) float64 {
+	r := st.opts.Rate
+	if !st.rate.used {
+		return float64(r.N)
+	}
+	refill := float64(now.Sub(st.rate.last)) / float64(r.Per) * float64(r.N)
+	return min(float64(r.N), st.rate.tok+refill)
+}
+
+// limited reports whether st has used up its rate for now.
+func (st *agentState) limited(now time.Time) bool {
+	return st.opts.Rate.enabled() && st.tokens(now) < 1
+}
+
+// take spends one token.
+func (st *agentState) take(now time.Time) {
+	if !st.opts.Rate.enabled() {
+		return
+	}
+	st.rate = bucket{used: true, tok: st.tokens(now) - 1, last: now}
+}
+
+// untilToken is how long until st has a whole token again.
+func (st *agentState) untilToken(now time.Time) time.Duration {
+	r := st.opts.Rate
+	missing := 1 - st.tokens(now)
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / float64(r.N) * float64(r.Per))
+}
+
+// RateRemaining returns how many invocations the agent may start right now
+// under its Rate, and false if it is unknown or has no Rate.
+func (r *Registry) RateRemaining(name string) (int, bool) {
+	name = r.canonical(name)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st, ok := r.state[name]
+	if !ok || !st.opts.Rate.enabled() {
+		return 0, false
+	}
+	return int(st.tokens(time.Now())), true
+}
+
+// refillTimer wakes selection waiters when a rate-limited agent gets a token
+// back, since no release or health change will.
+type refillTimer struct {
+	mu sync.Mutex
+	at time.Time // earliest pending wake-up; zero if none
+}
+
+// wakeAfter arranges for a notify after d unless an earlier one is pending.
+// Callers may hold r.mu for reading or writing.
+func (r *Registry) wakeAfter(d time.Duration) {
+	at := time.Now().Add(d)
+	rt := &r.refill
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if !rt.at.IsZero() && !rt.at.After(at) {
+		return
+	}
+	rt.at = at
+	time.AfterFunc(d, func() {
+		rt.mu.Lock()
+		if rt.at.Equal(at) {
+			rt.at = time.Time{}
+		}
+		rt.mu.Unlock()
+		r.mu.Lock()
+		r.notify()
+		r.mu.Unlock()
+	})
+}
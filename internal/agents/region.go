@@ -0,0 +1,50 @@
+package agents
+
+// Region-aware selection
+
+// SelectNearest prefers agents registered in region, round-robining within
+// that set. Only when no same-region agent is eligible does it fall back to
+// the regular Select over all regions.
+func (r *Registry) SelectNearest(taskType, region string) (Agent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.candidates(taskType)
+	if len(list) == 0 {
+		return r.selected(taskType, nil)
+	}
+	r.byType[taskType] = list
+
+	local := make([]Agent, 0, len(list))
+	for _, a := range list {
+		if st := r.state[a.Name()]; st != nil && st.opts.Region == region {
+			local = append(local, a)
+		}
+	}
+	if len(local) > 0 {
+		key := taskType + "@" + region
+		if i, _ := r.pick(taskType, local, r.rrIdx[key], nil); i >= 0 {
+			r.advance(key, local, i)
+			return r.selected(taskType, local[i])
+		}
+	}
+
+	i, _ := r.pick(taskType, list, r.rrIdx[taskType], nil)
+	if i < 0 {
+		return r.selected(taskType, nil)
+	}
+	r.advance(taskType, list, i)
+	return r.selected(taskType, list[i])
+}
+
+// Region returns the region an agent was registered in.
+func (r *Registry) Region(name string) (string, bool) {
+	name = r.canonical(name)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st, ok := r.state[name]
+	if !ok {
+		return "", false
+	}
+	return st.opts.Region, true
+}
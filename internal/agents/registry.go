@@ -3,25 +3,98 @@ package agents
 import (
 	"errors"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Registry provides threadsafe registration and selection of Agents by capability.
 // It supports round-robin selection per task type to spread load across agents.
 type Registry struct {
-	mu     sync.RWMutex
-	byName map[string]Agent   // agent name -> Agent
-	byType map[string][]Agent // taskType -> agents that can handle it
-	rrIdx  map[string]int     // taskType -> next round-robin index
+	mu      sync.RWMutex
+	byName  map[string]Agent   // agent name -> Agent
+	byType  map[string][]Agent // taskType -> agents that can handle it
+	rrIdx   map[string]int     // taskType -> next round-robin index
+	streak  map[string]int     // taskType -> picks in a row of the agent at rrIdx, see advance
+	state   map[string]*agentState
+	shadows map[string][]Agent // taskType -> shadow agents, see RegisterShadow
+
+	counters selectionCounters
+	events   eventHub
+	changed  chan struct{} // closed and replaced when an agent may have become selectable
+	refill   refillTimer
+
+	defaultNS string // see WithDefaultNamespace
+}
+
+// RegisterOptions carries per-agent settings supplied at registration.
+type RegisterOptions struct {
+	// Capacity caps concurrent executions tracked via Acquire/Release.
+	// Zero means unlimited.
+	Capacity int
+	// MaxTypeShare caps the fraction of Capacity a single task type may hold
+	// (0 < share <= 1) so one high-volume type cannot starve the others the
+	// agent handles. Zero disables the cap; it has no effect without Capacity.
+	MaxTypeShare float64
+	// Limits sandbox every execution on this agent (see RunLimited).
+	Limits Limits
+	// Region the agent is deployed in, used by SelectNearest.
+	Region string
+	// Group is the logical agent this one is a replica of, see Replicas.
+	// Empty means the agent stands alone.
+	Group string
+	// Weight is the agent's share of round-robin selection: it is chosen
+	// Weight times in a row before its turn passes on. Zero means 1.
+	Weight int
+	// Rate caps how often the agent is invoked, independently of Capacity.
+	// Select skips agents that have used it up. Zero means unlimited.
+	Rate Rate
+	// Namespace the agent is registered in, for agents whose Name() has
+	// none; it becomes part of the name the registry knows it by (see
+	// QualifiedName). Empty means the registry's default namespace.
+	Namespace string
+	// Probe, for agents implementing CapabilityProber, checks before the
+	// agent goes live that it serves the task types it is registered for,
+	// waiting at most ProbeTimeout (zero means DefaultProbeTimeout).
+	Probe        ProbeMode
+	ProbeTimeout time.Duration
+}
+
+// agentState is the mutable runtime view of a registered agent.
+type agentState struct {
+	opts      RegisterOptions
+	unhealthy bool
+	inflight  int
+	byType    map[string]int // in-flight count per task type
+	rate      bucket         // see RegisterOptions.Rate
+}
+
+// SelectDiagnostic explains the outcome of a selection: how many agents could
+// handle the type and why the ones that were not chosen got filtered out.
+type SelectDiagnostic struct {
+	TaskType    string
+	Candidates  int    // agents able to handle the type
+	Unhealthy   int    // filtered: marked unhealthy
+	AtCapacity  int    // filtered: in-flight count reached Capacity
+	RateLimited int    // filtered: used up its Rate for now
+	Excluded    int    // filtered: named in the caller's exclude list
+	Chosen      string // name of the selected agent; empty if none was eligible
 }
 
 // NewRegistry creates an empty agent registry.
-func NewRegistry() *Registry {
-	return &Registry{
-		byName: make(map[string]Agent),
-		byType: make(map[string][]Agent),
-		rrIdx:  make(map[string]int),
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{
+		byName:  make(map[string]Agent),
+		byType:  make(map[string][]Agent),
+		rrIdx:   make(map[string]int),
+		streak:  make(map[string]int),
+		state:   make(map[string]*agentState),
+		changed: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
 // Register adds an agent and indexes its capabilities. You can pass the task
@@ -30,21 +103,87 @@ func NewRegistry() *Registry {
 
 // Prefer: Register(a, "grade", "notify", "recommend")
 func (r *Registry) Register(a Agent, taskTypes ...string) error {
+	return r.RegisterWithOptions(a, RegisterOptions{}, taskTypes...)
+}
+
+// RegisterWithOptions is Register with per-agent settings such as capacity.
+//
+// Names only need to be unique within a namespace. An agent in a namespace
+// other than the default one is registered, and selected and looked up, by
+// its fully-qualified name ("team-a/grader"); one in the default namespace
+// by its bare name.
+//
+// With opts.Probe set, a failed probe fails registration with ErrProbeFailed
+// (ProbeRequire) or registers the agent unhealthy (ProbeMarkUnready). The
+// probe runs before the name is checked for duplicates.
+func (r *Registry) RegisterWithOptions(a Agent, opts RegisterOptions, taskTypes ...string) error {
 	if a == nil {
 		return errors.New("nil agent")
 	}
-	name := a.Name()
-	if name == "" {
+	if a.Name() == "" {
 		return errors.New("agent must have a non-empty Name()")
 	}
+	a, err := r.qualify(a, opts.Namespace)
+	if err != nil {
+		return err
+	}
+	name := a.Name()
+	var probeErr error
+	if opts.Probe != ProbeOff {
+		probeErr = probe(a, taskTypes, opts.ProbeTimeout)
+		if probeErr != nil && opts.Probe == ProbeRequire {
+			return probeErr
+		}
+	}
 
+	defer r.flushEvents()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.byName[name]; exists {
 		return errors.New("agent already registered: " + name)
 	}
+	r.insert(a, opts, taskTypes)
+	if probeErr != nil {
+		r.state[name].unhealthy = true
+		r.emit(EventHealthChanged, name, false)
+	}
+	return nil
+}
+
+// GetOrRegister registers a unless an agent with the same name is already
+// registered, in which case it returns that agent instead. The check and the
+// insert happen under one lock, so concurrent callers registering the same
+// name get exactly one winner; registered reports whether it was this call.
+func (r *Registry) GetOrRegister(a Agent, taskTypes ...string) (agent Agent, registered bool, err error) {
+	if a == nil {
+		return nil, false, errors.New("nil agent")
+	}
+	if a.Name() == "" {
+		return nil, false, errors.New("agent must have a non-empty Name()")
+	}
+	a, err = r.qualify(a, "")
+	if err != nil {
+		return nil, false, err
+	}
+	name := a.Name()
+
+	defer r.flushEvents()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.byName[name]; ok {
+		return existing, false, nil
+	}
+	r.insert(a, RegisterOptions{}, taskTypes)
+	return a, true, nil
+}
+
+// insert adds a, whose name must be free. Caller must hold r.mu.
+func (r *Registry) insert(a Agent, opts RegisterOptions, taskTypes []string) {
+	name := a.Name()
 	r.byName[name] = a
+	r.state[name] = &agentState{opts: opts}
 
 	// If explicit types not given, you can adapt this to your domain.
 	// For now, only index provided types to avoid guessing.
@@ -62,11 +201,14 @@ func (r *Registry) Register(a Agent, taskTypes ...string) error {
 			return r.byType[t][i].Name() < r.byType[t][j].Name()
 		})
 	}
-	return nil
+	r.emit(EventRegistered, name, true)
+	r.notify()
 }
 
 // Deregister removes an agent by name and unindexes it from all task types.
 func (r *Registry) Deregister(name string) bool {
+	name = r.canonical(name)
+	defer r.flushEvents()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -74,7 +216,16 @@ func (r *Registry) Deregister(name string) bool {
 	if !ok {
 		return false
 	}
+	r.remove(name)
+	return true
+}
+
+// remove unindexes a registered agent. Caller must hold r.mu.
+func (r *Registry) remove(name string) {
 	delete(r.byName, name)
+	delete(r.state, name)
+	delete(r.counters.timing, name)
+	r.emit(EventDeregistered, name, false)
 
 	// Remove from all type lists
 	for t, list := range r.byType {
@@ -86,20 +237,56 @@ func (r *Registry) Deregister(name string) bool {
 		}
 		if len(newList) == 0 {
 			delete(r.byType, t)
-			delete(r.rrIdx, t)
+			r.forgetCursors(t)
 		} else {
 			r.byType[t] = newList
+			delete(r.streak, t) // another agent may be at the cursor now
 			// Clamp RR index
 			if r.rrIdx[t] >= len(newList) {
 				r.rrIdx[t] = 0
 			}
 		}
 	}
-	return true
 }
 
-// Get returns an agent by its unique name.
+// forgetCursors drops the round-robin indexes of a task type that no agent
+// is indexed for anymore, including those kept by the filtered selections
+// ("type/payload", "type/best", "type@region"), which would otherwise
+// accumulate as agents come and go. Caller must hold r.mu.
+func (r *Registry) forgetCursors(taskType string) {
+	delete(r.rrIdx, taskType)
+	delete(r.streak, taskType)
+	for key := range r.rrIdx {
+		if strings.HasPrefix(key, taskType+"/") || strings.HasPrefix(key, taskType+"@") {
+			delete(r.rrIdx, key)
+			delete(r.streak, key)
+		}
+	}
+}
+
+// advance moves the round-robin cursor key past list[i], the agent just
+// chosen, once it has been chosen its Weight times in a row. Caller must hold
+// r.mu for writing.
+func (r *Registry) advance(key string, list []Agent, i int) {
+	w := 1
+	if st := r.state[list[i].Name()]; st != nil && st.opts.Weight > 1 {
+		w = st.opts.Weight
+	}
+	if r.rrIdx[key]%len(list) != i {
+		delete(r.streak, key) // the agent at the cursor was passed over
+	}
+	if r.streak[key]++; r.streak[key] < w {
+		r.rrIdx[key] = i
+		return
+	}
+	delete(r.streak, key)
+	r.rrIdx[key] = (i + 1) % len(list)
+}
+
+// Get returns an agent by its unique name, fully-qualified unless it is in
+// the default namespace (which may also be spelled out).
 func (r *Registry) Get(name string) (Agent, bool) {
+	name = r.canonical(name)
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	a, ok := r.byName[name]
@@ -107,29 +294,174 @@ func (r *Registry) Get(name string) (Agent, bool) {
 }
 
 // Select chooses an agent that can handle the given task type.
-// Uses round-robin across the set to balance load.
+// Uses round-robin across the set to balance load, skipping agents that are
+// unhealthy or at capacity.
 func (r *Registry) Select(taskType string) (Agent, bool) {
+	return r.SelectExcluding(taskType)
+}
+
+// SelectExcluding is Select but never returns one of the named agents, e.g.
+// the agent that just failed a task being retried.
+func (r *Registry) SelectExcluding(taskType string, exclude ...string) (Agent, bool) {
+	exclude = r.canonicalAll(exclude)
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	list := r.byType[taskType]
+	list := r.candidates(taskType)
 	if len(list) == 0 {
-		// Fallback: search all agents that say they can handle it (in case not indexed).
-		for _, a := range r.byName {
-			if a.CanHandle(taskType) {
-				list = append(list, a)
-			}
+		return r.selected(taskType, nil)
+	}
+	r.byType[taskType] = list
+
+	i, _ := r.pick(taskType, list, r.rrIdx[taskType], exclude)
+	if i < 0 {
+		return r.selected(taskType, nil)
+	}
+	r.advance(taskType, list, i)
+	return r.selected(taskType, list[i])
+}
+
+// SelectExplain reports what Select would do for taskType without changing
+// any state: the round-robin index is not advanced.
+func (r *Registry) SelectExplain(taskType string, exclude ...string) SelectDiagnostic {
+	exclude = r.canonicalAll(exclude)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := r.candidates(taskType)
+	i, d := r.pick(taskType, list, r.rrIdx[taskType], exclude)
+	if i >= 0 {
+		d.Chosen = list[i].Name()
+	}
+	return d
+}
+
+// candidates returns the agents indexed for taskType. Fallback: search all
+// agents that say they can handle it (in case not indexed). Caller must hold r.mu.
+func (r *Registry) candidates(taskType string) []Agent {
+	if list := r.byType[taskType]; len(list) > 0 {
+		return list
+	}
+	if len(r.byName) == 0 {
+		return nil
+	}
+	var list []Agent
+	for _, a := range r.byName {
+		if a.CanHandle(taskType) {
+			list = append(list, a)
 		}
-		if len(list) == 0 {
-			return nil, false
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// pick walks list in round-robin order from start and returns the position of
+// the first eligible agent (-1 if none), tallying why the others were
+// skipped. Caller must hold r.mu.
+func (r *Registry) pick(taskType string, list []Agent, start int, exclude []string) (int, SelectDiagnostic) {
+	d := SelectDiagnostic{TaskType: taskType, Candidates: len(list)}
+	if len(list) == 0 {
+		return -1, d
+	}
+	// Cursors are clamped on deregistration, but the filtered selections
+	// share them with lists of other lengths, so never trust one as is.
+	start %= len(list)
+	chosen := -1
+	now := time.Now()
+	var wait time.Duration // until the first rate-limited agent has a token
+	for n := 0; n < len(list); n++ {
+		i := (start + n) % len(list)
+		name := list[i].Name()
+		if contains(exclude, name) {
+			d.Excluded++
+			continue
+		}
+		if r.skip(&d, name, taskType, now, &wait) {
+			continue
+		}
+		if chosen < 0 {
+			chosen = i
 		}
-		r.byType[taskType] = list
 	}
+	if chosen < 0 && wait > 0 {
+		r.wakeAfter(wait)
+	}
+	return chosen, d
+}
+
+// skip reports whether the named agent must be passed over for taskType
+// because it is unhealthy, at capacity or out of rate, tallying the reason in
+// d. For a rate-limited agent it lowers *wait to the time until its next
+// token, so the caller can schedule a wake-up. Caller must hold r.mu.
+func (r *Registry) skip(d *SelectDiagnostic, name, taskType string, now time.Time, wait *time.Duration) bool {
+	st := r.state[name]
+	switch {
+	case st == nil:
+		return false
+	case st.unhealthy:
+		d.Unhealthy++
+	case st.full(taskType):
+		d.AtCapacity++
+	case st.limited(now):
+		d.RateLimited++
+		if w := st.untilToken(now); *wait == 0 || w < *wait {
+			*wait = w
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// SetHealthy marks an agent healthy or unhealthy. Unhealthy agents are skipped
+// by Select. Returns false if the agent is not registered.
+func (r *Registry) SetHealthy(name string, healthy bool) bool {
+	name = r.canonical(name)
+	defer r.flushEvents()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.state[name]
+	if !ok {
+		return false
+	}
+	if st.unhealthy == healthy {
+		r.emit(EventHealthChanged, name, healthy)
+	}
+	st.unhealthy = !healthy
+	if healthy {
+		r.notify()
+	}
+	return true
+}
 
-	i := r.rrIdx[taskType] % len(list)
-	a := list[i]
-	r.rrIdx[taskType] = (i + 1) % len(list)
-	return a, true
+// Limits returns the execution limits the agent was registered with.
+func (r *Registry) Limits(name string) (Limits, bool) {
+	name = r.canonical(name)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st, ok := r.state[name]
+	if !ok {
+		return Limits{}, false
+	}
+	return st.opts.Limits, true
+}
+
+// TaskTypes returns the sorted task types an agent is indexed for.
+func (r *Registry) TaskTypes(name string) []string {
+	name = r.canonical(name)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []string
+	for t, list := range r.byType {
+		for _, a := range list {
+			if a.Name() == name {
+				out = append(out, t)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
 }
 
 // List returns a snapshot of all registered agents.
@@ -157,3 +489,12 @@ func dedupe(in []string) []string {
 	}
 	return out
 }
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
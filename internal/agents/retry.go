@@ -0,0 +1,60 @@
+package agents
+
+// Per-agent retry policies
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryPolicy says whether and how a failed execution is attempted again.
+// The zero value means no retries.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts, the first one included; <= 1 disables retries
+	Backoff     time.Duration // delay before the first retry, doubled for each further one
+	MaxBackoff  time.Duration // caps the delay; zero means uncapped
+	// RetryOn lists the error kinds worth retrying, matched with errors.Is.
+	// Empty retries every error.
+	RetryOn []error
+}
+
+// NoRetry is the policy for agents with side effects that must not repeat.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// Retrier is implemented by agents that know how safe they are to retry. The
+// orchestrator prefers an agent's policy over its global one.
+type Retrier interface {
+	RetryPolicy() RetryPolicy
+}
+
+// Retryable reports whether attempt (1-based) failing with err may be followed
+// by another one.
+func (p RetryPolicy) Retryable(attempt int, err error) bool {
+	if err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	if len(p.RetryOn) == 0 {
+		return true
+	}
+	for _, target := range p.RetryOn {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns the wait before the retry that follows attempt (1-based).
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d > 0; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,75 @@
+package agents
+
+// Routing table snapshot for dashboards
+
+import (
+	"sort"
+	"time"
+)
+
+// AgentRouting is one agent's row in the routing table.
+type AgentRouting struct {
+	Name      string
+	TaskTypes []string // types the agent is indexed under, sorted
+	Healthy   bool
+	Weight    int
+	InFlight  int
+
+	// RateRemaining is how many invocations the agent may start right now
+	// under its Rate; -1 if it has none.
+	RateRemaining int
+}
+
+// Describe returns the routing table, sorted by agent name, as one snapshot
+// taken under the read lock, so names, types, health and in-flight counts
+// are consistent with each other. It is meant for frequent polling: the
+// TaskTypes of all rows share a single backing array.
+func (r *Registry) Describe() []AgentRouting {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	out := make([]AgentRouting, 0, len(r.byName))
+	for name := range r.byName {
+		row := AgentRouting{Name: name, Healthy: true, Weight: 1, RateRemaining: -1}
+		if st := r.state[name]; st != nil {
+			row.Healthy, row.InFlight = !st.unhealthy, st.inflight
+			if st.opts.Weight > 0 {
+				row.Weight = st.opts.Weight
+			}
+			if st.opts.Rate.enabled() {
+				row.RateRemaining = int(st.tokens(now))
+			}
+		}
+		out = append(out, row)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+
+	// Count each agent's types, then carve one array into per-row slices.
+	idx := make(map[string]int, len(out))
+	for i, row := range out {
+		idx[row.Name] = i
+	}
+	counts := make([]int, len(out))
+	total := 0
+	for _, list := range r.byType {
+		for _, a := range list {
+			counts[idx[a.Name()]]++
+			total++
+		}
+	}
+	backing := make([]string, total)
+	for i := range out {
+		out[i].TaskTypes, backing = backing[:0:counts[i]], backing[counts[i]:]
+	}
+	for t, list := range r.byType {
+		for _, a := range list {
+			i := idx[a.Name()]
+			out[i].TaskTypes = append(out[i].TaskTypes, t)
+		}
+	}
+	for i := range out {
+		sort.Strings(out[i].TaskTypes)
+	}
+	return out
+}
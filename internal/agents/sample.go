@@ -0,0 +1,55 @@
+package agents
+
+// Sample agent demonstrating the partial-result convention
+
+import (
+	"context"
+	"errors"
+)
+
+// ItemAgent processes Payload["items"] one element at a time with Step. If
+// the context is cancelled between items it returns a partial result holding
+// the finished outputs under "done" and the unprocessed items under
+// Output[RemainingKey], so a retry only redoes what is left.
+type ItemAgent struct {
+	AgentName string
+	TaskType  string
+	Step      func(ctx context.Context, item any) (any, error)
+}
+
+func (a *ItemAgent) Name() string { return a.AgentName }
+
+func (a *ItemAgent) CanHandle(taskType string) bool { return taskType == a.TaskType }
+
+func (a *ItemAgent) Execute(ctx context.Context, t Task) (Result, error) {
+	items, _ := t.Payload["items"].([]any)
+	done := make([]any, 0, len(items))
+
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return partialItems(t.ID, done, items[i:], err), err
+		}
+		out, err := a.Step(ctx, item)
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			// Step noticed the deadline itself; item i still needs doing.
+			return partialItems(t.ID, done, items[i:], err), err
+		}
+		if err != nil {
+			return Result{TaskID: t.ID, Status: StatusFailed, Error: err.Error()}, err
+		}
+		done = append(done, out)
+	}
+	return Result{TaskID: t.ID, Status: StatusOK, Output: map[string]any{"done": done}}, nil
+}
+
+func partialItems(taskID string, done, rest []any, err error) Result {
+	return Result{
+		TaskID: taskID,
+		Status: StatusPartial,
+		Output: map[string]any{
+			"done":       done,
+			RemainingKey: map[string]any{"items": rest},
+		},
+		Error: err.Error(),
+	}
+}
@@ -0,0 +1,129 @@
+package agents
+
+// Execution sandbox: per-agent resource limits around Execute
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+var (
+	ErrAgentBlocked  = errors.New("agent blocked past its timeout")
+	ErrGoroutineLeak = errors.New("agent leaked goroutines")
+	ErrMemoryBudget  = errors.New("agent exceeded its memory budget")
+)
+
+// Limits bounds a single Execute call. Zero values disable each check.
+//
+// Go offers no per-goroutine accounting, so MaxGoroutines and MemoryBudget
+// are measured process-wide around the call. They are soft guards meant to
+// catch runaway agents, not precise quotas; with many concurrent executions
+// set them generously.
+type Limits struct {
+	Timeout time.Duration // deadline applied to Execute's context
+	// Grace is how long Execute may keep running after its context is done
+	// before it is abandoned and the task fails with ErrAgentBlocked.
+	// Defaults to one second when Timeout is set.
+	Grace time.Duration
+	// MaxGoroutines is how many goroutines may outlive Execute. Goroutines
+	// tied to the cancelled context get up to Grace to exit before they
+	// count. The count is process-wide, so it is only reliable while this
+	// agent's executions run one at a time with nothing else executing
+	// (one worker, or an otherwise idle process).
+	MaxGoroutines int
+	MemoryBudget  uint64 // heap growth in bytes allowed during Execute
+}
+
+const memSampleEvery = 10 * time.Millisecond
+
+// RunLimited executes t on a under l. The context handed to the agent is
+// always cancelled when the call returns, so goroutines the agent tied to it
+// wind down.
+func RunLimited(ctx context.Context, a Agent, t Task, l Limits) (Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if l.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+		defer cancel()
+	}
+	grace := l.Grace
+	if grace <= 0 {
+		grace = time.Second
+	}
+
+	before := runtime.NumGoroutine()
+	heap0 := heapBytes()
+
+	type outcome struct {
+		res Result
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		res, err := a.Execute(ctx, t)
+		done <- outcome{res, err}
+	}()
+
+	var sample <-chan time.Time
+	if l.MemoryBudget > 0 {
+		tick := time.NewTicker(memSampleEvery)
+		defer tick.Stop()
+		sample = tick.C
+	}
+
+	var out outcome
+wait:
+	for {
+		select {
+		case out = <-done:
+			break wait
+		case <-sample:
+			if heapBytes() > heap0+l.MemoryBudget {
+				cancel()
+				return failedWith(t.ID, ErrMemoryBudget)
+			}
+		case <-ctx.Done():
+			select {
+			case out = <-done:
+				break wait
+			case <-time.After(grace):
+				return failedWith(t.ID, ErrAgentBlocked)
+			}
+		}
+	}
+
+	cancel()
+	if l.MaxGoroutines > 0 && !settles(before+l.MaxGoroutines, grace) {
+		return failedWith(t.ID, ErrGoroutineLeak)
+	}
+	return out.res, out.err
+}
+
+// settles reports whether the goroutine count drops to at most limit within
+// grace, giving goroutines that watch a just-cancelled context time to exit.
+func settles(limit int, grace time.Duration) bool {
+	deadline := time.Now().Add(grace)
+	for runtime.NumGoroutine() > limit {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(memSampleEvery)
+	}
+	return true
+}
+
+func failedWith(taskID string, err error) (Result, error) {
+	return Result{TaskID: taskID, Status: StatusFailed, Error: err.Error()}, err
+}
+
+func heapBytes() uint64 {
+	s := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(s)
+	if s[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return s[0].Value.Uint64()
+}
@@ -0,0 +1,65 @@
+package agents
+
+// Reproducible selection independent of the shared round-robin state
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+)
+
+// Selection is an isolated selection sequence. It rotates through agents like
+// Select, but from its own per-type position, derived from a seed, instead of
+// the registry's shared round-robin index. Given the same seed, agents and
+// health/capacity state, the same sequence of calls yields the same agents no
+// matter what other callers select in the meantime.
+type Selection struct {
+	r    *Registry
+	seed int64
+
+	mu   sync.Mutex
+	next map[string]int // taskType -> calls made so far
+}
+
+// NewSelection starts an isolated selection sequence for seed.
+func (r *Registry) NewSelection(seed int64) *Selection {
+	return &Selection{r: r, seed: seed, next: make(map[string]int)}
+}
+
+// Select chooses the next eligible agent for taskType in this sequence.
+func (s *Selection) Select(taskType string) (Agent, bool) {
+	s.mu.Lock()
+	n := s.next[taskType]
+	s.next[taskType] = n + 1
+	s.mu.Unlock()
+	return s.r.selectSeeded(taskType, s.seed, n)
+}
+
+// SelectWithSeed is a one-off deterministic selection: the same seed always
+// picks the same agent for the same candidates and state.
+func (r *Registry) SelectWithSeed(taskType string, seed int64) (Agent, bool) {
+	return r.selectSeeded(taskType, seed, 0)
+}
+
+// selectSeeded picks with a start position derived from seed, taskType and
+// step. Candidates are in name order, so the position is meaningful.
+func (r *Registry) selectSeeded(taskType string, seed int64, step int) (Agent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.candidates(taskType)
+	if len(list) == 0 {
+		return r.selected(taskType, nil)
+	}
+	h := fnv.New64a()
+	h.Write([]byte(strconv.FormatInt(seed, 10)))
+	h.Write([]byte{0})
+	h.Write([]byte(taskType))
+	start := int((h.Sum64() + uint64(step)) % uint64(len(list)))
+
+	i, _ := r.pick(taskType, list, start, nil)
+	if i < 0 {
+		return r.selected(taskType, nil)
+	}
+	return r.selected(taskType, list[i])
+}
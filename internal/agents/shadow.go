@@ -0,0 +1,57 @@
+package agents
+
+// Shadow agents: run alongside the selected agent for comparison only
+
+import "errors"
+
+// RegisterShadow adds a as a shadow for taskType. Shadows are never returned
+// by Select; an orchestrator runs them next to the selected agent and records
+// their result without using it. A shadow may also be registered as a regular
+// agent under a different name. Shadows are named like regular agents: a
+// Name() without a namespace is in the default one.
+func (r *Registry) RegisterShadow(a Agent, taskType string) error {
+	if a == nil {
+		return errors.New("nil agent")
+	}
+	if a.Name() == "" {
+		return errors.New("agent must have a non-empty Name()")
+	}
+	a, err := r.qualify(a, "")
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.shadows == nil {
+		r.shadows = make(map[string][]Agent)
+	}
+	for _, s := range r.shadows[taskType] {
+		if s.Name() == a.Name() {
+			return errors.New("shadow already registered: " + a.Name())
+		}
+	}
+	r.shadows[taskType] = append(r.shadows[taskType], a)
+	return nil
+}
+
+// DeregisterShadow removes the named shadow for taskType.
+func (r *Registry) DeregisterShadow(name, taskType string) bool {
+	name = r.canonical(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := r.shadows[taskType]
+	for i, s := range list {
+		if s.Name() == name {
+			r.shadows[taskType] = append(list[:i:i], list[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Shadows returns the shadow agents registered for taskType.
+func (r *Registry) Shadows(taskType string) []Agent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Agent(nil), r.shadows[taskType]...)
+}
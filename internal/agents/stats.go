@@ -0,0 +1,130 @@
+package agents
+
+// Selection statistics
+
+import "time"
+
+// DefaultSoftDeadline is the fraction of an execution's timeout past which it
+// counts as slow, when WithSoftDeadline is not given.
+const DefaultSoftDeadline = 0.8
+
+// slowWindow is about how many recent executions SlowFraction reflects.
+const slowWindow = 20
+
+// SelectionStats counts selection outcomes since the registry was created.
+type SelectionStats struct {
+	Selected map[string]map[string]uint64 // agent name -> task type -> times chosen
+	Misses   map[string]uint64            // task type -> selections that found no agent
+
+	// Timing tracks, per registered agent name, how close executions come
+	// to their timeout, see ObserveExecution.
+	Timing map[string]TimeoutBudget
+}
+
+// TimeoutBudget counts an agent's timed executions and those that went past
+// the soft deadline. SlowFraction is an exponentially weighted average over
+// roughly the last 20 executions rather than Slow / Executions, so after a
+// long uptime it still rises as soon as an agent starts drifting towards its
+// timeout, before it actually starts timing out.
+type TimeoutBudget struct {
+	Executions   uint64
+	Slow         uint64  // took at least the soft-deadline fraction of their timeout
+	SlowFraction float64 // recent share of slow executions
+}
+
+type selectionCounters struct {
+	selected map[string]map[string]uint64
+	misses   map[string]uint64
+	timing   map[string]TimeoutBudget
+
+	softDeadline float64 // see WithSoftDeadline; zero means DefaultSoftDeadline
+}
+
+// WithSoftDeadline sets the fraction (0 < f <= 1) of an execution's timeout
+// past which ObserveExecution counts it as slow.
+func WithSoftDeadline(f float64) RegistryOption {
+	return func(r *Registry) { r.counters.softDeadline = f }
+}
+
+// ObserveExecution records that an execution on the named agent took took
+// out of a timeout of timeout. Executions without a timeout are ignored: they
+// have no budget to approach, and so are those of agents no longer registered.
+// The orchestrator calls it after every attempt.
+func (r *Registry) ObserveExecution(name string, took, timeout time.Duration) {
+	name = r.canonical(name)
+	if timeout <= 0 {
+		return
+	}
+	soft := r.counters.softDeadline
+	if soft <= 0 || soft > 1 {
+		soft = DefaultSoftDeadline
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.state[name]; !ok {
+		return // deregistered while it ran
+	}
+	c := &r.counters
+	if c.timing == nil {
+		c.timing = make(map[string]TimeoutBudget)
+	}
+	b := c.timing[name]
+	b.Executions++
+	slow := 0.0
+	if float64(took) >= soft*float64(timeout) {
+		b.Slow++
+		slow = 1
+	}
+	// Until the window fills, weigh every execution equally.
+	alpha := max(2.0/(slowWindow+1), 1/float64(b.Executions))
+	b.SlowFraction += alpha * (slow - b.SlowFraction)
+	c.timing[name] = b
+}
+
+// selected records the outcome of a selection and passes it through. Caller
+// must hold r.mu for writing.
+func (r *Registry) selected(taskType string, a Agent) (Agent, bool) {
+	c := &r.counters
+	if a == nil {
+		if c.misses == nil {
+			c.misses = make(map[string]uint64)
+		}
+		c.misses[taskType]++
+		return nil, false
+	}
+	if c.selected == nil {
+		c.selected = make(map[string]map[string]uint64)
+	}
+	byType := c.selected[a.Name()]
+	if byType == nil {
+		byType = make(map[string]uint64)
+		c.selected[a.Name()] = byType
+	}
+	byType[taskType]++
+	return a, true
+}
+
+// Stats returns a copy of the selection counters and timeout budgets.
+func (r *Registry) Stats() SelectionStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := SelectionStats{
+		Selected: make(map[string]map[string]uint64, len(r.counters.selected)),
+		Misses:   make(map[string]uint64, len(r.counters.misses)),
+		Timing:   make(map[string]TimeoutBudget, len(r.counters.timing)),
+	}
+	for name, byType := range r.counters.selected {
+		cp := make(map[string]uint64, len(byType))
+		for t, n := range byType {
+			cp[t] = n
+		}
+		out.Selected[name] = cp
+	}
+	for t, n := range r.counters.misses {
+		out.Misses[t] = n
+	}
+	for name, b := range r.counters.timing {
+		out.Timing[name] = b
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package agents
+
+// Streaming agents
+// Agents that produce their output piece by piece can hand each piece out as
+// it is ready, for live views, while still returning one final result.
+
+import "context"
+
+// ResultChunk is one piece of a streaming agent's output. Seq numbers an
+// execution's chunks from 1, so a consumer can tell when it missed some; the
+// orchestrator fills in TaskID and Seq.
+type ResultChunk struct {
+	TaskID string         `json:"taskId"`
+	Seq    int            `json:"seq"`
+	Output map[string]any `json:"output"`
+}
+
+// StreamingAgent is an Agent that can report partial output while it runs.
+// ExecuteStream calls send for every chunk, in order, and returns the
+// aggregated result, as Execute would. send never blocks for long; chunks a
+// slow consumer cannot keep up with are dropped, never the final result.
+type StreamingAgent interface {
+	Agent
+	ExecuteStream(ctx context.Context, t Task, send func(ResultChunk)) (Result, error)
+}
+
+// Streamed adapts a to a plain Agent whose Execute streams chunks to send.
+func Streamed(a StreamingAgent, send func(ResultChunk)) Agent {
+	return streamed{a, send}
+}
+
+type streamed struct {
+	StreamingAgent
+	send func(ResultChunk)
+}
+
+func (s streamed) Execute(ctx context.Context, t Task) (Result, error) {
+	return s.ExecuteStream(ctx, t, s.send)
+}
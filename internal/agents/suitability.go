@@ -0,0 +1,88 @@
+package agents
+
+// Suitability-scored selection
+
+import (
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// Suitable is an optional Agent extension. Among agents that CanHandle a
+// task, SelectBest prefers the one reporting the highest suitability for its
+// type and payload. Implementations must be cheap and must not call back into
+// the registry: they run under its lock.
+type Suitable interface {
+	Suitability(taskType string, payload map[string]any) float64
+}
+
+// NeutralSuitability is the score of agents that do not implement Suitable.
+const NeutralSuitability = 0.5
+
+// SelectBest picks the most suitable healthy, under-capacity agent for t
+// among those that accept its payload (see PayloadMatcher). Round-robin only
+// breaks ties between equally suitable agents.
+func (r *Registry) SelectBest(t tasks.Task) (Agent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.candidates(t.Type)
+	if len(list) == 0 {
+		return r.selected(t.Type, nil)
+	}
+	r.byType[t.Type] = list
+
+	best, _ := r.best(t, list)
+	if len(best) == 0 {
+		return r.selected(t.Type, nil)
+	}
+
+	key := t.Type + "/best"
+	i := r.rrIdx[key] % len(best)
+	r.advance(key, best, i)
+	return r.selected(t.Type, best[i])
+}
+
+// SelectBestExplain reports what SelectBest would do for t without changing
+// any state. Agents that reject the payload are not tallied under any reason.
+func (r *Registry) SelectBestExplain(t tasks.Task) SelectDiagnostic {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	best, d := r.best(t, r.candidates(t.Type))
+	if len(best) > 0 {
+		d.Chosen = best[r.rrIdx[t.Type+"/best"]%len(best)].Name()
+	}
+	return d
+}
+
+// best returns the eligible agents in list sharing the top suitability for t.
+// When none is eligible but some are only out of rate, it schedules a wake-up
+// for their next token so that WaitForAgent callers are not left hanging.
+// Caller must hold r.mu.
+func (r *Registry) best(t tasks.Task, list []Agent) ([]Agent, SelectDiagnostic) {
+	d := SelectDiagnostic{TaskType: t.Type, Candidates: len(list)}
+	now := time.Now()
+	var wait time.Duration
+	var best []Agent
+	top := 0.0
+	for _, a := range list {
+		if r.skip(&d, a.Name(), t.Type, now, &wait) || !handlesPayload(a, t.Type, t.Payload) {
+			continue
+		}
+		score := NeutralSuitability
+		if s, ok := As[Suitable](a); ok {
+			score = s.Suitability(t.Type, t.Payload)
+		}
+		switch {
+		case len(best) == 0 || score > top:
+			best, top = append(best[:0], a), score
+		case score == top:
+			best = append(best, a)
+		}
+	}
+	if len(best) == 0 && wait > 0 {
+		r.wakeAfter(wait)
+	}
+	return best, d
+}
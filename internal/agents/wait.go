@@ -0,0 +1,53 @@
+package agents
+
+// Blocking selection
+
+import "context"
+
+// SelectWait is Select that, instead of failing when every capable agent is
+// unhealthy or at capacity, blocks until one becomes available or ctx is
+// done. It wakes on capacity releases, health recoveries and registrations.
+// If no registered agent can handle taskType at all it keeps waiting for one
+// to be registered.
+//
+// The returned agent is not reserved; call AcquireFor and, if another
+// caller won the slot, SelectWait again.
+func (r *Registry) SelectWait(ctx context.Context, taskType string) (Agent, error) {
+	for {
+		r.mu.Lock()
+		list := r.candidates(taskType)
+		if len(list) > 0 {
+			r.byType[taskType] = list
+			if i, _ := r.pick(taskType, list, r.rrIdx[taskType], nil); i >= 0 {
+				r.advance(taskType, list, i)
+				a, _ := r.selected(taskType, list[i])
+				r.mu.Unlock()
+				return a, nil
+			}
+		}
+		changed := r.changed
+		r.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Changed returns a channel that is closed the next time an agent may have
+// become selectable: on registration, health recovery or capacity release.
+// Take it before a failed selection attempt to wait for a retry without
+// missing a wake-up.
+func (r *Registry) Changed() <-chan struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.changed
+}
+
+// notify wakes every SelectWait. Caller must hold r.mu for writing.
+func (r *Registry) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
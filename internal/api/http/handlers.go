@@ -1,2 +1,334 @@
 package http
-// Endpoints: /orchestrate, /criteria, /agents etc
\ No newline at end of file
+
+// Endpoints: /orchestrate, /criteria, /agents etc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/criteria"
+	"github.com/ngx-workshop/mcp-server/internal/orchestrator"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+const maxBodyBytes = 1 << 20
+
+// runStreamPoll is how often a run stream rechecks whether its run ended.
+const runStreamPoll = time.Second
+
+// Server holds the dependencies the handlers need.
+type Server struct {
+	Orchestrator *orchestrator.Orchestrator
+	Results      tasks.ResultStore
+	Registry     *agents.Registry
+
+	// RunContext is the parent context for submitted runs; runs must outlive
+	// the request that started them. Defaults to context.Background().
+	RunContext context.Context
+}
+
+type criterionJSON struct {
+	Key    string  `json:"key"`
+	Value  float64 `json:"value"`
+	Weight float64 `json:"weight"`
+	Source string  `json:"source,omitempty"`
+}
+
+type criteriaJSON struct {
+	LearnerID string          `json:"learnerId"`
+	CourseID  string          `json:"courseId"`
+	Items     []criterionJSON `json:"items"`
+}
+
+type resultJSON struct {
+	TaskID string         `json:"taskId"`
+	Status string         `json:"status"`
+	Output map[string]any `json:"output,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Reason string         `json:"cancelReason,omitempty"`
+}
+
+type runJSON struct {
+	ID         string      `json:"id"`
+	Status     string      `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	StartedAt  time.Time   `json:"startedAt"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+	Report     *reportJSON `json:"report,omitempty"`
+}
+
+type reportJSON struct {
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Partial   int          `json:"partial"`
+	Results   []resultJSON `json:"results"`
+
+	Ineligible    string             `json:"ineligible,omitempty"`
+	Compensations []compensationJSON `json:"compensations,omitempty"`
+}
+
+type compensationJSON struct {
+	TaskID   string `json:"taskId"`
+	TaskType string `json:"taskType"`
+	Error    string `json:"error,omitempty"`
+}
+
+type agentJSON struct {
+	Name         string         `json:"name"`
+	Namespace    string         `json:"namespace,omitempty"`
+	TaskTypes    []string       `json:"taskTypes"`
+	Version      string         `json:"version,omitempty"`
+	Capacity     int            `json:"capacity,omitempty"`
+	Capabilities map[string]any `json:"capabilities,omitempty"`
+	Replicas     []string       `json:"replicas,omitempty"`
+}
+
+// handleCreateRun: POST /runs
+func (s *Server) handleCreateRun(w http.ResponseWriter, r *http.Request) {
+	var in criteriaJSON
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", err)
+		return
+	}
+	c, err := in.toCriteria()
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_request", err)
+		return
+	}
+
+	ctx := s.RunContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	id, err := s.Orchestrator.Submit(ctx, c)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err)
+		return
+	}
+	w.Header().Set("Location", "/runs/"+id)
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": id})
+}
+
+// handleGetRun: GET /runs/{id}
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	info, ok := s.Orchestrator.RunStatus(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", errors.New("unknown run"))
+		return
+	}
+	writeJSON(w, http.StatusOK, toRunJSON(info))
+}
+
+func toRunJSON(info orchestrator.RunInfo) runJSON {
+	out := runJSON{ID: info.ID, Status: info.Status, StartedAt: info.StartedAt}
+	if info.Err != nil {
+		out.Error = info.Err.Error()
+	}
+	if info.Status != orchestrator.RunRunning {
+		out.FinishedAt = &info.FinishedAt
+		rep := &reportJSON{
+			Succeeded: info.Report.Succeeded,
+			Failed:    info.Report.Failed,
+			Partial:   info.Report.Partial,
+			Results:   make([]resultJSON, 0, len(info.Report.Results)),
+
+			Ineligible: info.Report.Ineligible,
+		}
+		for _, res := range info.Report.Results {
+			rep.Results = append(rep.Results, toResultJSON(res))
+		}
+		for _, c := range info.Report.Compensations {
+			cj := compensationJSON{TaskID: c.TaskID, TaskType: c.TaskType}
+			if c.Err != nil {
+				cj.Error = c.Err.Error()
+			}
+			rep.Compensations = append(rep.Compensations, cj)
+		}
+		out.Report = rep
+	}
+	return out
+}
+
+// handleGetResult: GET /results/{taskID}
+func (s *Server) handleGetResult(w http.ResponseWriter, r *http.Request) {
+	if s.Results == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", errors.New("no result store configured"))
+		return
+	}
+	res, ok, err := s.Results.Get(r.Context(), r.PathValue("taskID"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", errors.New("unknown task"))
+		return
+	}
+	writeJSON(w, http.StatusOK, toResultJSON(res))
+}
+
+// handleListAgents: GET /agents
+func (s *Server) handleListAgents(w http.ResponseWriter, r *http.Request) {
+	list := s.Registry.Descriptions()
+	out := make([]agentJSON, 0, len(list))
+	for _, d := range list {
+		if d.TaskTypes == nil {
+			d.TaskTypes = []string{}
+		}
+		out = append(out, agentJSON{
+			Name: d.Name, Namespace: d.Namespace, TaskTypes: d.TaskTypes, Version: d.Version,
+			Capacity: d.Capacity, Capabilities: d.Capabilities, Replicas: d.Replicas,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (in criteriaJSON) toCriteria() (criteria.Criteria, error) {
+	if in.LearnerID == "" {
+		return criteria.Criteria{}, errors.New("learnerId is required")
+	}
+	if len(in.Items) == 0 {
+		return criteria.Criteria{}, errors.New("items must not be empty")
+	}
+	c := criteria.Criteria{LearnerID: in.LearnerID, CourseID: in.CourseID}
+	for i, it := range in.Items {
+		if it.Key == "" {
+			return criteria.Criteria{}, fmt.Errorf("items[%d].key is required", i)
+		}
+		if it.Weight < 0 {
+			return criteria.Criteria{}, fmt.Errorf("items[%d].weight must be non-negative", i)
+		}
+		c.Items = append(c.Items, criteria.Criterion{Key: it.Key, Value: it.Value, Weight: it.Weight, Source: it.Source})
+	}
+	return c, nil
+}
+
+func toResultJSON(res tasks.Result) resultJSON {
+	out := resultJSON{TaskID: res.TaskID, Status: res.Status, Output: res.Output}
+	if res.Err != nil {
+		out.Error = res.Err.Error()
+	}
+	if res.CancelReason != tasks.CancelNone {
+		out.Reason = res.CancelReason.String()
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError renders {"error": {"code": ..., "message": ...}}.
+func writeError(w http.ResponseWriter, status int, code string, err error) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]string{"code": code, "message": err.Error()},
+	})
+}
+
+// handleAgentEvents: GET /agents/events, a server-sent event stream of
+// registry changes.
+func (s *Server) handleAgentEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "not_supported", errors.New("streaming unsupported"))
+		return
+	}
+	events, cancel := s.Registry.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Kind, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// handleRunStream: GET /runs/{id}/stream, a server-sent event stream of the
+// run's streaming agent output. Every orchestrator.EventChunk of the run is
+// sent as a "chunk" event; a final "result" event carries the run with its
+// aggregated report, after which the stream ends. A run that already ended
+// gets only the final event.
+//
+// Chunks come from a subscription on the orchestrator's Bus, so a client
+// that reads too slowly loses chunks as the bus's DropPolicy says instead of
+// holding up agents. Gaps show as jumps in a task's chunk seq. The final
+// event does not depend on the bus: should the end-of-run event be dropped
+// too, the run status is rechecked every runStreamPoll.
+func (s *Server) handleRunStream(w http.ResponseWriter, r *http.Request) {
+	bus := s.Orchestrator.Bus
+	if bus == nil {
+		writeError(w, http.StatusNotImplemented, "not_supported", errors.New("run streaming needs an event bus"))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "not_supported", errors.New("streaming unsupported"))
+		return
+	}
+	id := r.PathValue("id")
+	// Subscribe before the status check so the end of the run cannot slip
+	// in between.
+	events := bus.Subscribe(orchestrator.TopicAll)
+	defer bus.Unsubscribe(events)
+	info, ok := s.Orchestrator.RunStatus(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", errors.New("unknown run"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	poll := time.NewTicker(runStreamPoll)
+	defer poll.Stop()
+	for info.Status == orchestrator.RunRunning {
+		var e orchestrator.Event
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+			info, _ = s.Orchestrator.RunStatus(id)
+			continue
+		case e, ok = <-events:
+			if !ok {
+				return
+			}
+		}
+		if e.RunID != id {
+			continue
+		}
+		switch e.Kind {
+		case orchestrator.EventChunk:
+			data, _ := json.Marshal(e.Chunk)
+			fmt.Fprintf(w, "event: chunk\ndata: %s\n\n", data)
+			flusher.Flush()
+		case orchestrator.EventRunDone:
+			info, _ = s.Orchestrator.RunStatus(id)
+		}
+	}
+	data, _ := json.Marshal(toRunJSON(info))
+	fmt.Fprintf(w, "event: result\ndata: %s\n\n", data)
+	flusher.Flush()
+}
@@ -1,2 +1,38 @@
 package http
-// mux + middleware (auth, tracing, CORS)
\ No newline at end of file
+
+// mux + middleware (auth, tracing, CORS)
+
+import (
+	"net/http"
+
+	"github.com/ngx-workshop/mcp-server/internal/middleware"
+	"github.com/ngx-workshop/mcp-server/internal/security"
+)
+
+// gzipThreshold is the response size from which bodies are gzip-encoded for
+// clients that accept it.
+const gzipThreshold = 1024
+
+// NewRouter wires the API routes behind the auth middleware. Request and
+// response bodies may be gzip-encoded, see middleware.Gzip.
+func NewRouter(s *Server, auth security.Authenticator) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /runs", s.handleCreateRun)
+	mux.HandleFunc("GET /runs/{id}", s.handleGetRun)
+	mux.HandleFunc("GET /runs/{id}/stream", s.handleRunStream)
+	mux.HandleFunc("GET /results/{taskID}", s.handleGetResult)
+	mux.HandleFunc("GET /agents", s.handleListAgents)
+	mux.HandleFunc("GET /agents/events", s.handleAgentEvents)
+	return middleware.Authz(auth)(middleware.Gzip(gzipThreshold)(s.withLoadHeader(mux)))
+}
+
+// withLoadHeader reports the orchestrator's load level on every response so
+// clients can throttle before they get rejected.
+func (s *Server) withLoadHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Orchestrator != nil {
+			w.Header().Set("X-Load-Level", s.Orchestrator.LoadLevel().String())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
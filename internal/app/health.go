@@ -0,0 +1,178 @@
+package app
+
+// Health and readiness aggregation for probes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// DefaultCheckTimeout bounds each check when Health.Timeout is zero.
+const DefaultCheckTimeout = 2 * time.Second
+
+// Check probes one subsystem and returns nil when it is healthy.
+type Check func(ctx context.Context) error
+
+// CheckResult is one entry of a HealthReport.
+type CheckResult struct {
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"durationNs"`
+}
+
+// HealthReport is the structured health document served by the probes.
+type HealthReport struct {
+	Ready  bool          `json:"ready"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Health aggregates registered checks. The process is ready when every check
+// passes. The zero value is ready to use.
+type Health struct {
+	Timeout time.Duration // per check; zero means DefaultCheckTimeout
+
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+// Register adds or replaces the check called name.
+func (h *Health) Register(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.checks == nil {
+		h.checks = make(map[string]Check)
+	}
+	h.checks[name] = check
+}
+
+// Health runs every check concurrently and returns the report, checks sorted
+// by name.
+func (h *Health) Health(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	checks := make([]Check, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.RUnlock()
+
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	rep := HealthReport{Ready: true, Checks: make([]CheckResult, len(names))}
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			began := time.Now()
+			err := checks[i](cctx)
+			res := CheckResult{Name: names[i], OK: err == nil, Duration: time.Since(began)}
+			if err != nil {
+				res.Error = err.Error()
+			}
+			rep.Checks[i] = res
+		}()
+	}
+	wg.Wait()
+	for _, c := range rep.Checks {
+		rep.Ready = rep.Ready && c.OK
+	}
+	return rep
+}
+
+// LiveHandler serves the health document with 200 as long as the process
+// can answer; failing dependencies should not get it restarted. Use it for
+// /healthz.
+func (h *Health) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, h.Health(r.Context()))
+	})
+}
+
+// ReadyHandler serves the health document with 503 while any check fails.
+// Use it for /readyz.
+func (h *Health) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep := h.Health(r.Context())
+		status := http.StatusOK
+		if !rep.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeReport(w, status, rep)
+	})
+}
+
+func writeReport(w http.ResponseWriter, status int, rep HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(rep)
+}
+
+// Pinger is implemented by backends that can report reachability.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck checks a backend via its Ping method.
+func PingCheck(p Pinger) Check {
+	return p.Ping
+}
+
+// depthReporter is implemented by queues that expose their depth, like
+// *tasks.MemoryQueue.
+type depthReporter interface {
+	Len() (pending, inflight int)
+}
+
+// QueueCheck fails when q is unreachable (if it is a Pinger) or when more than
+// maxPending tasks are waiting (if it reports its depth). Zero maxPending
+// skips the depth bound.
+func QueueCheck(q tasks.Queue, maxPending int) Check {
+	return func(ctx context.Context) error {
+		if p, ok := q.(Pinger); ok {
+			if err := p.Ping(ctx); err != nil {
+				return fmt.Errorf("queue unreachable: %w", err)
+			}
+		}
+		if d, ok := q.(depthReporter); ok && maxPending > 0 {
+			if pending, _ := d.Len(); pending > maxPending {
+				return fmt.Errorf("queue depth %d exceeds %d", pending, maxPending)
+			}
+		}
+		return nil
+	}
+}
+
+// RegistryCheck fails unless every critical task type has at least one
+// healthy agent.
+func RegistryCheck(r *agents.Registry, criticalTypes ...string) Check {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, typ := range criticalTypes {
+			d := r.SelectExplain(typ)
+			if d.Candidates-d.Unhealthy <= 0 {
+				errs = append(errs, fmt.Errorf("no healthy agent for %q", typ))
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
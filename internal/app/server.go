@@ -1,2 +1,15 @@
 package app
+
 // Compose HTTP servers, routes, middleware
+
+import "net/http"
+
+// NewHandler serves /healthz and /readyz from h without authentication, so
+// probes need no credentials, and everything else from api.
+func NewHandler(api http.Handler, h *Health) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /healthz", h.LiveHandler())
+	mux.Handle("GET /readyz", h.ReadyHandler())
+	mux.Handle("/", api)
+	return mux
+}
@@ -0,0 +1,38 @@
+package criteria
+
+// Aggregation of Criteria into a Score
+
+import "errors"
+
+var (
+	ErrNoCriteria = errors.New("criteria has no items")
+	ErrZeroWeight = errors.New("criteria weights sum to zero")
+)
+
+// AggregateOptions tunes Aggregate. The zero value is the plain weighted mean.
+type AggregateOptions struct {
+	// Outliers, when set, screens item values before weighting.
+	Outliers *OutlierPolicy
+
+	// Weigher computes effective weights; nil uses each item's static Weight.
+	// Context fills in the course and learner metadata it sees; LearnerID and
+	// CourseID default to the Criteria's.
+	Weigher Weigher
+	Context WeighContext
+
+	// Cohort, when set, scores items relative to a cohort (see
+	// NormalizeToCohort) instead of unifying their kinds.
+	Cohort *CohortNormalization
+
+	// Profiles override static weights per course (see WeightProfiles),
+	// looked up by the Criteria's CourseID, before Weigher runs.
+	Profiles WeightProfiles
+}
+
+// Aggregate combines c's items into their weighted mean. Items of different
+// kinds are first converted to ratios (see Criterion.Ratio); kinds with no
+// conversion rule make it fail with ErrIncompatibleKinds. It runs
+// DefaultPipeline(opts); build a Pipeline to reorder or add stages.
+func Aggregate(c Criteria, opts AggregateOptions) (Score, error) {
+	return DefaultPipeline(opts).Run(c)
+}
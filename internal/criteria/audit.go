@@ -0,0 +1,117 @@
+package criteria
+
+// Audit trail of criterion value changes, for grade appeals
+// AuditedStore wraps a Store and records who set or changed each criterion
+// value, when, and from what, taking the actor from the request's claims.
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/security"
+)
+
+// AuditChange says what happened to a criterion in an update.
+type AuditChange string
+
+const (
+	AuditSet     AuditChange = "set"     // the criterion is new; Old is zero
+	AuditUpdate  AuditChange = "update"  // its value changed
+	AuditRemoved AuditChange = "removed" // the update dropped it; New is zero
+)
+
+// CriterionAudit records one change of one criterion's value.
+type CriterionAudit struct {
+	LearnerID string
+	CourseID  string
+	Key       string
+	Change    AuditChange
+	Old, New  float64
+	Source    string // the criterion's Source; the old one for removals
+	Actor     string // Subject of the caller's claims; empty without any
+	At        time.Time
+	Version   int // the criteria version the change produced
+}
+
+// AuditedStore is a Store that keeps an audit trail of every value change
+// written through it. Writes are serialized so each one is diffed against
+// the state it replaces. Use UpdateContext to attribute changes; Update
+// records them without an actor.
+type AuditedStore struct {
+	Store Store
+
+	mu    sync.Mutex
+	trail map[[2]string][]CriterionAudit
+	now   func() time.Time
+}
+
+// NewAuditedStore wraps s.
+func NewAuditedStore(s Store) *AuditedStore {
+	return &AuditedStore{Store: s, trail: make(map[[2]string][]CriterionAudit), now: time.Now}
+}
+
+func (s *AuditedStore) Get(learnerID, courseID string) (Criteria, bool) {
+	return s.Store.Get(learnerID, courseID)
+}
+
+func (s *AuditedStore) Update(c Criteria, expectedVersion int) error {
+	return s.UpdateContext(context.Background(), c, expectedVersion)
+}
+
+// UpdateContext is Update attributing the changes to the principal that the
+// auth middleware stored in ctx (see security.ClaimsFromContext). Nothing is
+// recorded when the underlying store rejects the write.
+func (s *AuditedStore) UpdateContext(ctx context.Context, c Criteria, expectedVersion int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, _ := s.Store.Get(c.LearnerID, c.CourseID)
+	if err := s.Store.Update(c, expectedVersion); err != nil {
+		return err
+	}
+	var actor string
+	if claims, ok := security.ClaimsFromContext(ctx); ok {
+		actor = claims.Subject
+	}
+	base := CriterionAudit{
+		LearnerID: c.LearnerID, CourseID: c.CourseID,
+		Actor: actor, At: s.now(), Version: expectedVersion + 1,
+	}
+	k := [2]string{c.LearnerID, c.CourseID}
+	old := make(map[string]Criterion, len(prev.Items))
+	for _, it := range prev.Items {
+		old[it.Key] = it
+	}
+	for _, it := range c.Items {
+		e := base
+		e.Key, e.New, e.Source = it.Key, it.Value, it.Source
+		was, existed := old[it.Key]
+		delete(old, it.Key)
+		switch {
+		case !existed:
+			e.Change = AuditSet
+		case was.Value != it.Value:
+			e.Change, e.Old = AuditUpdate, was.Value
+		default:
+			continue
+		}
+		s.trail[k] = append(s.trail[k], e)
+	}
+	for _, it := range prev.Items { // in stored order
+		if _, gone := old[it.Key]; !gone {
+			continue
+		}
+		e := base
+		e.Key, e.Change, e.Old, e.Source = it.Key, AuditRemoved, it.Value, it.Source
+		s.trail[k] = append(s.trail[k], e)
+	}
+	return nil
+}
+
+// AuditTrail returns every recorded change of the learner's criteria in the
+// course, oldest first.
+func (s *AuditedStore) AuditTrail(learnerID, courseID string) []CriterionAudit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CriterionAudit(nil), s.trail[[2]string{learnerID, courseID}]...)
+}
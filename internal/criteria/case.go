@@ -0,0 +1,271 @@
+package criteria
+
+// IMS CASE (Competencies and Academic Standards Exchange) import/export
+// Criteria map to a CFPackage: the course is the CFDocument, every criterion
+// is a CFItem plus a CFRubricCriterion carrying its weight and value.
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// CFPackage is the subset of the CASE 1.0 package JSON this mapping uses.
+type CFPackage struct {
+	CFDocument CFDocument `json:"CFDocument"`
+	CFItems    []CFItem   `json:"CFItems"`
+	CFRubrics  []CFRubric `json:"CFRubrics,omitempty"`
+}
+
+type CFDocument struct {
+	Identifier string `json:"identifier"`
+	URI        string `json:"uri"`
+	Title      string `json:"title"`
+	Creator    string `json:"creator"`
+}
+
+type CFItem struct {
+	Identifier           string `json:"identifier"`
+	URI                  string `json:"uri"`
+	FullStatement        string `json:"fullStatement"`
+	HumanCodingScheme    string `json:"humanCodingScheme,omitempty"`
+	AbbreviatedStatement string `json:"abbreviatedStatement,omitempty"`
+	CFItemType           string `json:"CFItemType,omitempty"`
+}
+
+type CFRubric struct {
+	Identifier       string              `json:"identifier"`
+	URI              string              `json:"uri"`
+	Title            string              `json:"title"`
+	CFRubricCriteria []CFRubricCriterion `json:"CFRubricCriteria"`
+}
+
+type CFRubricCriterion struct {
+	Identifier              string                   `json:"identifier"`
+	URI                     string                   `json:"uri"`
+	CFItemURI               string                   `json:"CFItemURI"`
+	Weight                  float64                  `json:"weight"`
+	Position                int                      `json:"position"`
+	CFRubricCriterionLevels []CFRubricCriterionLevel `json:"CFRubricCriterionLevels,omitempty"`
+}
+
+type CFRubricCriterionLevel struct {
+	Identifier  string  `json:"identifier"`
+	URI         string  `json:"uri"`
+	Description string  `json:"description,omitempty"`
+	Score       float64 `json:"score"`
+	Position    int     `json:"position"`
+}
+
+// CASE item fields a CASEMapping can point at.
+const (
+	CASEHumanCodingScheme    = "humanCodingScheme"
+	CASEAbbreviatedStatement = "abbreviatedStatement"
+	CASEFullStatement        = "fullStatement"
+	CASEItemType             = "CFItemType"
+)
+
+// CASEMapping is the mapping table between Criterion fields and CASE
+// structures. The zero value uses the defaults noted on each field.
+type CASEMapping struct {
+	KeyField    string // CFItem field holding Criterion.Key; default humanCodingScheme
+	SourceField string // CFItem field holding Criterion.Source; default CFItemType, "-" drops it
+	BaseURI     string // prefix for generated URIs; default "urn:mcp-server:case:"
+	Creator     string // CFDocument.creator on export
+}
+
+// Level positions of a criterion's rubric levels.
+const (
+	caseValueLevel = 1 // score is Criterion.Value
+	caseMaxLevel   = 2 // score is Criterion.Max, when set
+)
+
+// LossyField names data that does not survive a CASE round trip.
+type LossyField struct {
+	Key    string // criterion key; empty for criteria-level fields
+	Field  string
+	Reason string
+}
+
+var ErrInvalidCASE = errors.New("invalid CASE package")
+
+func (m CASEMapping) keyField() string {
+	if m.KeyField == "" {
+		return CASEHumanCodingScheme
+	}
+	return m.KeyField
+}
+
+func (m CASEMapping) sourceField() string {
+	if m.SourceField == "" {
+		return CASEItemType
+	}
+	return m.SourceField
+}
+
+func (m CASEMapping) uri(id string) string {
+	if m.BaseURI == "" {
+		return "urn:mcp-server:case:" + id
+	}
+	return m.BaseURI + id
+}
+
+// caseID derives a stable UUID-formatted identifier from parts, so exporting
+// the same course twice yields the same identifiers.
+func caseID(parts ...string) string {
+	h := sha1.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	b := h.Sum(nil)
+	b[6] = b[6]&0x0f | 0x50 // version 5 layout
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// kindNamed reverses Kind.String; unknown names are KindUnspecified.
+func kindNamed(name string) Kind {
+	for k := KindUnspecified; k <= KindZScore; k++ {
+		if k.String() == name {
+			return k
+		}
+	}
+	return KindUnspecified
+}
+
+func setItemField(it *CFItem, field, v string) error {
+	switch field {
+	case CASEHumanCodingScheme:
+		it.HumanCodingScheme = v
+	case CASEAbbreviatedStatement:
+		it.AbbreviatedStatement = v
+	case CASEFullStatement:
+		it.FullStatement = v
+	case CASEItemType:
+		it.CFItemType = v
+	case "-":
+	default:
+		return fmt.Errorf("%w: unknown CFItem field %q in mapping", ErrInvalidCASE, field)
+	}
+	return nil
+}
+
+func itemField(it CFItem, field string) string {
+	switch field {
+	case CASEHumanCodingScheme:
+		return it.HumanCodingScheme
+	case CASEAbbreviatedStatement:
+		return it.AbbreviatedStatement
+	case CASEFullStatement:
+		return it.FullStatement
+	case CASEItemType:
+		return it.CFItemType
+	}
+	return ""
+}
+
+// ExportCASE converts c into a CASE package. Fields CASE has no place for are
+// listed in the returned LossyFields; the export itself still succeeds.
+func ExportCASE(c Criteria, m CASEMapping) (CFPackage, []LossyField, error) {
+	docID := caseID("doc", c.CourseID)
+	p := CFPackage{CFDocument: CFDocument{
+		Identifier: docID, URI: m.uri(docID), Title: c.CourseID, Creator: m.Creator,
+	}}
+	rubricID := caseID("rubric", c.CourseID)
+	rubric := CFRubric{Identifier: rubricID, URI: m.uri(rubricID), Title: c.CourseID}
+
+	var lossy []LossyField
+	if c.LearnerID != "" {
+		lossy = append(lossy, LossyField{Field: "LearnerID", Reason: "CASE describes frameworks, not learners"})
+	}
+	for i, it := range c.Items {
+		itemID := caseID("item", c.CourseID, it.Key)
+		item := CFItem{Identifier: itemID, URI: m.uri(itemID), FullStatement: it.Key}
+		if err := setItemField(&item, m.keyField(), it.Key); err != nil {
+			return CFPackage{}, nil, err
+		}
+		if it.Source != "" {
+			if m.sourceField() == "-" {
+				lossy = append(lossy, LossyField{Key: it.Key, Field: "Source", Reason: "mapping drops it"})
+			} else if err := setItemField(&item, m.sourceField(), it.Source); err != nil {
+				return CFPackage{}, nil, err
+			}
+		}
+		p.CFItems = append(p.CFItems, item)
+
+		critID := caseID("criterion", c.CourseID, it.Key)
+		crit := CFRubricCriterion{
+			Identifier: critID, URI: m.uri(critID), CFItemURI: item.URI,
+			Weight: it.Weight, Position: i + 1,
+		}
+		levelID := caseID("level", c.CourseID, it.Key, "value")
+		crit.CFRubricCriterionLevels = append(crit.CFRubricCriterionLevels, CFRubricCriterionLevel{
+			Identifier: levelID, URI: m.uri(levelID), Description: it.Kind.String(),
+			Score: it.Value, Position: caseValueLevel,
+		})
+		if it.Max != 0 {
+			maxID := caseID("level", c.CourseID, it.Key, "max")
+			crit.CFRubricCriterionLevels = append(crit.CFRubricCriterionLevels, CFRubricCriterionLevel{
+				Identifier: maxID, URI: m.uri(maxID), Description: "max", Score: it.Max, Position: caseMaxLevel,
+			})
+		}
+		rubric.CFRubricCriteria = append(rubric.CFRubricCriteria, crit)
+		if it.Visibility != VisibilityPublic {
+			lossy = append(lossy, LossyField{Key: it.Key, Field: "Visibility", Reason: "CASE has no access levels"})
+		}
+		if len(it.Prerequisites) > 0 {
+			lossy = append(lossy, LossyField{Key: it.Key, Field: "Prerequisites", Reason: "CASE associations carry no thresholds"})
+		}
+	}
+	p.CFRubrics = []CFRubric{rubric}
+	return p, lossy, nil
+}
+
+// ImportCASE converts a CASE package back into Criteria, one item per CFItem
+// in package order. Items without a rubric criterion get weight 1 and value 0
+// and are reported as lossy.
+func ImportCASE(p CFPackage, m CASEMapping) (Criteria, []LossyField, error) {
+	c := Criteria{CourseID: p.CFDocument.Title}
+	crits := make(map[string]CFRubricCriterion)
+	for _, r := range p.CFRubrics {
+		for _, rc := range r.CFRubricCriteria {
+			crits[rc.CFItemURI] = rc
+		}
+	}
+
+	var lossy []LossyField
+	for i, item := range p.CFItems {
+		key := itemField(item, m.keyField())
+		if key == "" {
+			return Criteria{}, nil, fmt.Errorf("%w: CFItems[%d] has no %s", ErrInvalidCASE, i, m.keyField())
+		}
+		it := Criterion{Key: key, Weight: 1}
+		if m.sourceField() != "-" {
+			it.Source = itemField(item, m.sourceField())
+		}
+		rc, ok := crits[item.URI]
+		if !ok {
+			lossy = append(lossy, LossyField{Key: key, Field: "Value", Reason: "no rubric criterion; defaulted"})
+			c.Items = append(c.Items, it)
+			continue
+		}
+		it.Weight = rc.Weight
+		for _, lvl := range rc.CFRubricCriterionLevels {
+			switch lvl.Position {
+			case caseValueLevel:
+				it.Value, it.Kind = lvl.Score, kindNamed(lvl.Description)
+			case caseMaxLevel:
+				it.Max = lvl.Score
+			default:
+				lossy = append(lossy, LossyField{
+					Key: key, Field: "CFRubricCriterionLevels[" + strconv.Itoa(lvl.Position) + "]",
+					Reason: "only value and max levels are mapped",
+				})
+			}
+		}
+		c.Items = append(c.Items, it)
+	}
+	return c, lossy, nil
+}
@@ -0,0 +1,87 @@
+package criteria
+
+// Norm-referenced scoring against cohort statistics
+// Instead of judging a value by its unit (criterion-referenced), each item
+// is expressed as a z-score: how many standard deviations it lies from the
+// cohort's mean for the same criterion key.
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CohortStat is the distribution of one criterion key's raw values across a
+// cohort.
+type CohortStat struct {
+	Mean   float64
+	StdDev float64
+}
+
+// CohortStats maps criterion keys to their cohort distribution.
+type CohortStats map[string]CohortStat
+
+// CohortStatsOf computes the per-key mean and population standard deviation
+// of the raw item values in cohort.
+func CohortStatsOf(cohort []Criteria) CohortStats {
+	byKey := make(map[string][]float64)
+	for _, c := range cohort {
+		for _, it := range c.Items {
+			byKey[it.Key] = append(byKey[it.Key], it.Value)
+		}
+	}
+	out := make(CohortStats, len(byKey))
+	for k, v := range byKey {
+		mean, sd := meanStddev(v)
+		out[k] = CohortStat{Mean: mean, StdDev: sd}
+	}
+	return out
+}
+
+// CohortFallback decides what happens to items without usable cohort
+// statistics: no entry for their key, or a zero standard deviation.
+type CohortFallback int
+
+const (
+	FallbackRaw      CohortFallback = iota // keep the raw value and kind
+	FallbackAbsolute                       // convert to a ratio, see Criterion.Ratio
+)
+
+// CohortNormalization configures NormalizeToCohort.
+type CohortNormalization struct {
+	Stats    CohortStats
+	Fallback CohortFallback
+}
+
+// NormalizeToCohort replaces every item's Value with its z-score against
+// n.Stats and sets its Kind to KindZScore and Max to zero. Items without
+// usable statistics follow n.Fallback; with FallbackAbsolute, an item that
+// has no ratio conversion makes the stage fail with ErrIncompatibleKinds.
+//
+// Z-scores are unit-free, so in DefaultPipeline this stage takes the place
+// of UnifyKinds rather than running after it.
+func NormalizeToCohort(n CohortNormalization) Stage {
+	return StageFunc(func(c Criteria) (Criteria, error) {
+		items := make([]Criterion, len(c.Items))
+		var errs []error
+		for i, it := range c.Items {
+			st, ok := n.Stats[it.Key]
+			switch {
+			case ok && st.StdDev > 0:
+				it.Value, it.Kind, it.Max = (it.Value-st.Mean)/st.StdDev, KindZScore, 0
+			case n.Fallback == FallbackAbsolute:
+				r, err := it.Ratio()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("no cohort statistics: %w", err))
+					continue
+				}
+				it.Value, it.Kind, it.Max = r, KindRatio, 0
+			}
+			items[i] = it
+		}
+		if len(errs) > 0 {
+			return Criteria{}, errors.Join(errs...)
+		}
+		c.Items = items
+		return c, nil
+	})
+}
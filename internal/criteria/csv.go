@@ -0,0 +1,187 @@
+package criteria
+
+// CSV/spreadsheet import
+// Turns instructor gradebook exports (one criterion per row) into Criteria.
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ColumnMapping names the header columns that hold each Criterion field.
+// Weight and Source are optional; rows without a weight column get weight 1.
+type ColumnMapping struct {
+	LearnerID string
+	CourseID  string
+	Key       string
+	Value     string
+	Weight    string
+	Source    string
+
+	Delimiter rune // defaults to ','
+}
+
+// RowError describes one rejected CSV row.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e RowError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+
+// ImportError collects every malformed row of an import.
+type ImportError struct {
+	Rows []RowError
+}
+
+func (e *ImportError) Error() string {
+	msgs := make([]string, len(e.Rows))
+	for i, r := range e.Rows {
+		msgs[i] = r.Error()
+	}
+	return fmt.Sprintf("%d malformed rows: %s", len(e.Rows), strings.Join(msgs, "; "))
+}
+
+// ParseCSV reads a header row followed by one criterion per row and groups
+// them into one Criteria per (learner, course), in first-seen order.
+// Malformed rows are skipped and reported together in an *ImportError, which
+// is returned alongside the rows that did parse. Any other read error ends
+// the import.
+func ParseCSV(r io.Reader, mapping ColumnMapping) ([]Criteria, error) {
+	cr := csv.NewReader(r)
+	if mapping.Delimiter != 0 {
+		cr.Comma = mapping.Delimiter
+	}
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	cols, err := mapping.resolve(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out    []Criteria
+		index  = make(map[[2]string]int)
+		report ImportError
+	)
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// FieldPos has nothing to report for a record that did not
+			// parse; the error says where it started.
+			var pe *csv.ParseError
+			if !errors.As(err, &pe) {
+				return nil, fmt.Errorf("read row: %w", err)
+			}
+			report.Rows = append(report.Rows, RowError{Line: pe.StartLine, Err: err})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+
+		learner, course, c, err := cols.row(rec)
+		if err != nil {
+			report.Rows = append(report.Rows, RowError{Line: line, Err: err})
+			continue
+		}
+		k := [2]string{learner, course}
+		i, ok := index[k]
+		if !ok {
+			i = len(out)
+			index[k] = i
+			out = append(out, Criteria{LearnerID: learner, CourseID: course})
+		}
+		out[i].Items = append(out[i].Items, c)
+	}
+
+	if len(report.Rows) > 0 {
+		return out, &report
+	}
+	return out, nil
+}
+
+// columns holds resolved header positions; -1 means not mapped.
+type columns struct {
+	learner, course, key, value, weight, source int
+}
+
+func (m ColumnMapping) resolve(header []string) (columns, error) {
+	pos := make(map[string]int, len(header))
+	for i, h := range header {
+		pos[strings.TrimSpace(h)] = i
+	}
+	find := func(name string, required bool) (int, error) {
+		if name == "" {
+			if required {
+				return -1, errors.New("column mapping is incomplete")
+			}
+			return -1, nil
+		}
+		i, ok := pos[name]
+		if !ok {
+			return -1, fmt.Errorf("column %q not found in header", name)
+		}
+		return i, nil
+	}
+
+	var c columns
+	var err error
+	for _, f := range []struct {
+		dst      *int
+		name     string
+		required bool
+	}{
+		{&c.learner, m.LearnerID, true},
+		{&c.course, m.CourseID, false},
+		{&c.key, m.Key, true},
+		{&c.value, m.Value, true},
+		{&c.weight, m.Weight, false},
+		{&c.source, m.Source, false},
+	} {
+		if *f.dst, err = find(f.name, f.required); err != nil {
+			return columns{}, err
+		}
+	}
+	return c, nil
+}
+
+func (c columns) row(rec []string) (learner, course string, crit Criterion, err error) {
+	field := func(i int) string {
+		if i < 0 || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	learner, course = field(c.learner), field(c.course)
+	if learner == "" {
+		return "", "", Criterion{}, errors.New("missing learner id")
+	}
+	crit = Criterion{Key: field(c.key), Weight: 1, Source: field(c.source)}
+	if crit.Key == "" {
+		return "", "", Criterion{}, errors.New("missing criterion key")
+	}
+	if crit.Value, err = strconv.ParseFloat(field(c.value), 64); err != nil {
+		return "", "", Criterion{}, fmt.Errorf("value: %w", err)
+	}
+	if w := field(c.weight); w != "" {
+		if crit.Weight, err = strconv.ParseFloat(w, 64); err != nil {
+			return "", "", Criterion{}, fmt.Errorf("weight: %w", err)
+		}
+		if crit.Weight < 0 {
+			return "", "", Criterion{}, errors.New("weight must be non-negative")
+		}
+	}
+	return learner, course, crit, nil
+}
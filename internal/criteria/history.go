@@ -0,0 +1,152 @@
+package criteria
+
+// Criterion time-series history and trends
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type TimedValue struct {
+	At    time.Time
+	Value float64
+}
+
+// Retention bounds a history. Zero fields disable that bound.
+type Retention struct {
+	MaxAge    time.Duration // drop points older than the newest point minus MaxAge
+	MaxPoints int           // keep at most this many points per criterion key
+}
+
+// CriterionHistory records timestamped values of one learner's criteria in
+// one course. It is safe for concurrent use.
+type CriterionHistory struct {
+	LearnerID string
+	CourseID  string
+
+	mu        sync.RWMutex
+	retention Retention
+	series    map[string][]TimedValue // criterion key -> points sorted by time
+}
+
+func NewCriterionHistory(learnerID, courseID string, retention Retention) *CriterionHistory {
+	return &CriterionHistory{
+		LearnerID: learnerID,
+		CourseID:  courseID,
+		retention: retention,
+		series:    make(map[string][]TimedValue),
+	}
+}
+
+// Append records c.Value for c.Key at the given time and applies retention.
+func (h *CriterionHistory) Append(c Criterion, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.series[c.Key]
+	i := sort.Search(len(s), func(i int) bool { return s[i].At.After(at) })
+	s = append(s, TimedValue{})
+	copy(s[i+1:], s[i:])
+	s[i] = TimedValue{At: at, Value: c.Value}
+
+	if h.retention.MaxAge > 0 {
+		cutoff := s[len(s)-1].At.Add(-h.retention.MaxAge)
+		drop := sort.Search(len(s), func(i int) bool { return !s[i].At.Before(cutoff) })
+		s = s[drop:]
+	}
+	if n := h.retention.MaxPoints; n > 0 && len(s) > n {
+		s = s[len(s)-n:]
+	}
+	h.series[c.Key] = s
+}
+
+// Series returns a copy of the recorded points for key, oldest first.
+func (h *CriterionHistory) Series(key string) []TimedValue {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]TimedValue(nil), h.series[key]...)
+}
+
+type Trend int
+
+const (
+	TrendFlat Trend = iota
+	TrendImproving
+	TrendDeclining
+)
+
+func (t Trend) String() string {
+	switch t {
+	case TrendImproving:
+		return "improving"
+	case TrendDeclining:
+		return "declining"
+	default:
+		return "flat"
+	}
+}
+
+// Trend fits a least-squares line through key's series and reports the
+// direction of its slope. Slopes within epsilon (value units per day) of zero,
+// and series with fewer than two points, are flat.
+func (h *CriterionHistory) Trend(key string, epsilon float64) Trend {
+	s := h.Series(key)
+	if len(s) < 2 {
+		return TrendFlat
+	}
+	t0 := s[0].At
+	var sx, sy, sxx, sxy float64
+	for _, p := range s {
+		x := p.At.Sub(t0).Hours() / 24
+		sx += x
+		sy += p.Value
+		sxx += x * x
+		sxy += x * p.Value
+	}
+	n := float64(len(s))
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return TrendFlat
+	}
+	slope := (n*sxy - sx*sy) / den
+	switch {
+	case slope > epsilon:
+		return TrendImproving
+	case slope < -epsilon:
+		return TrendDeclining
+	}
+	return TrendFlat
+}
+
+// HistoryStore hands out one CriterionHistory per (learner, course).
+type HistoryStore struct {
+	mu        sync.Mutex
+	retention Retention
+	byLearner map[[2]string]*CriterionHistory
+}
+
+func NewHistoryStore(retention Retention) *HistoryStore {
+	return &HistoryStore{retention: retention, byLearner: make(map[[2]string]*CriterionHistory)}
+}
+
+// For returns the history for a learner in a course, creating it on first use.
+func (s *HistoryStore) For(learnerID, courseID string) *CriterionHistory {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := [2]string{learnerID, courseID}
+	h, ok := s.byLearner[k]
+	if !ok {
+		h = NewCriterionHistory(learnerID, courseID, s.retention)
+		s.byLearner[k] = h
+	}
+	return h
+}
+
+// Record appends every item of c at the given time.
+func (s *HistoryStore) Record(c Criteria, at time.Time) {
+	h := s.For(c.LearnerID, c.CourseID)
+	for _, it := range c.Items {
+		h.Append(it, at)
+	}
+}
@@ -0,0 +1,102 @@
+package criteria
+
+// Criterion units and conversion to a common scale
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind is the unit a Criterion's Value is expressed in.
+type Kind int
+
+const (
+	KindUnspecified Kind = iota
+	KindPercent          // 0-100
+	KindRatio            // 0-1
+	KindCount            // a tally; convertible with Max
+	KindBoolean          // zero is false, anything else true
+	KindPoints           // rubric points; convertible with Max
+	KindZScore           // standard deviations from the cohort mean, see NormalizeToCohort
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindUnspecified:
+		return "unspecified"
+	case KindPercent:
+		return "percent"
+	case KindRatio:
+		return "ratio"
+	case KindCount:
+		return "count"
+	case KindBoolean:
+		return "boolean"
+	case KindPoints:
+		return "points"
+	case KindZScore:
+		return "zscore"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+var ErrIncompatibleKinds = errors.New("criteria kinds cannot be combined")
+
+// Ratio converts c.Value to the 0-1 scale: percent/100, boolean to 0 or 1,
+// count and points divided by Max. Unspecified kinds, z-scores, and counts
+// or points without a positive Max, have no conversion rule.
+func (c Criterion) Ratio() (float64, error) {
+	switch c.Kind {
+	case KindRatio:
+		return c.Value, nil
+	case KindPercent:
+		return c.Value / 100, nil
+	case KindBoolean:
+		if c.Value != 0 {
+			return 1, nil
+		}
+		return 0, nil
+	case KindCount, KindPoints:
+		if c.Max > 0 {
+			return c.Value / c.Max, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q is %s without a conversion rule", ErrIncompatibleKinds, c.Key, c.Kind)
+}
+
+// unifyKinds converts every item to KindRatio, so booleans become 0 or 1
+// and percentages fractions even when no other kind is present. Items that
+// all share a kind with no conversion rule (which includes legacy criteria
+// with no kind at all) are left alone; otherwise any item that cannot be
+// converted is an error.
+func unifyKinds(items []Criterion) ([]Criterion, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+	same := true
+	for _, it := range items[1:] {
+		if it.Kind != items[0].Kind {
+			same = false
+			break
+		}
+	}
+
+	out := make([]Criterion, len(items))
+	var errs []error
+	for i, it := range items {
+		r, err := it.Ratio()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		it.Value, it.Kind, it.Max = r, KindRatio, 0
+		out[i] = it
+	}
+	if same && len(errs) == len(items) {
+		return items, nil
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
@@ -9,10 +9,30 @@ type Criterion struct {
 	Value  float64
 	Weight float64
 	Source string // quiz, rubric, behavior, etc.
+
+	Kind Kind    // unit of Value; KindUnspecified keeps legacy raw semantics
+	Max  float64 // full marks for KindCount/KindPoints; enables conversion to a ratio
+
+	Visibility Visibility // who may see the item, see Filter; empty is public
+
+	Prerequisites []Prerequisite // see CheckPrerequisites
 }
 
 type Criteria struct {
 	LearnerID string
 	CourseID  string
 	Items     []Criterion
+
+	// Version counts the stored revisions, see Store.Update. Zero means the
+	// criteria were never stored.
+	Version int
+}
+
+// Score is the aggregate of one learner's Criteria.
+type Score struct {
+	LearnerID string
+	CourseID  string
+	Value     float64   // weighted mean of the contributing item values
+	Weight    float64   // total weight that contributed
+	Outliers  []Outlier // items flagged, adjusted or excluded before weighting
 }
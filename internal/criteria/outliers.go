@@ -0,0 +1,175 @@
+package criteria
+
+// Outlier screening for aggregation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+type OutlierMethod int
+
+const (
+	OutlierZScore OutlierMethod = iota // |value - mean| / stddev of the other values > Threshold (default 3)
+	OutlierIQR                         // outside [Q1 - k*IQR, Q3 + k*IQR], k = Threshold (default 1.5)
+	OutlierClamp                       // outside [Min, Max]; clamped into range unless excluded
+)
+
+type OutlierAction int
+
+const (
+	OutlierFlag    OutlierAction = iota // report, keep the value (OutlierClamp still clamps)
+	OutlierExclude                      // report and drop the item
+)
+
+// OutlierPolicy selects how outliers are detected and handled.
+type OutlierPolicy struct {
+	Method    OutlierMethod
+	Action    OutlierAction
+	Threshold float64
+	Min, Max  float64 // OutlierClamp range
+}
+
+// Outlier records one affected item.
+type Outlier struct {
+	Key      string
+	Value    float64 // original value
+	Adjusted float64 // value used for aggregation (equals Value unless clamped)
+	Excluded bool
+	Reason   string
+}
+
+// apply returns the items to aggregate and the outliers it found. The input
+// slice is not modified.
+func (p *OutlierPolicy) apply(items []Criterion) ([]Criterion, []Outlier) {
+	ranges := p.ranges(items)
+	if ranges == nil {
+		return items, nil
+	}
+
+	kept := make([]Criterion, 0, len(items))
+	var found []Outlier
+	for i, it := range items {
+		r := ranges[i]
+		if !r.ok || it.Value >= r.lo && it.Value <= r.hi {
+			kept = append(kept, it)
+			continue
+		}
+		o := Outlier{Key: it.Key, Value: it.Value, Adjusted: it.Value,
+			Reason: fmt.Sprintf("%s: outside [%g, %g]", p.Method, r.lo, r.hi)}
+		switch {
+		case p.Action == OutlierExclude:
+			o.Excluded = true
+		case p.Method == OutlierClamp:
+			o.Adjusted = math.Min(math.Max(it.Value, r.lo), r.hi)
+			it.Value = o.Adjusted
+			kept = append(kept, it)
+		default:
+			kept = append(kept, it)
+		}
+		found = append(found, o)
+	}
+	return kept, found
+}
+
+// valueRange is the acceptable range for one item; ok is false when that
+// item cannot be judged.
+type valueRange struct {
+	lo, hi float64
+	ok     bool
+}
+
+// ranges computes the acceptable value range of every item, or nil when the
+// sample is too small to judge.
+//
+// The z-score of an item is taken against the mean and standard deviation of
+// the other items. Including the item itself would cap |z| at (n-1)/sqrt(n),
+// so with the default threshold of 3 nothing could be flagged below 11
+// items. It needs at least 3 items, and an item whose others are all equal
+// is not judged.
+func (p *OutlierPolicy) ranges(items []Criterion) []valueRange {
+	out := make([]valueRange, len(items))
+	switch p.Method {
+	case OutlierClamp:
+		if p.Min > p.Max {
+			return nil
+		}
+		for i := range out {
+			out[i] = valueRange{p.Min, p.Max, true}
+		}
+	case OutlierIQR:
+		if len(items) < 4 {
+			return nil
+		}
+		vals := values(items)
+		sort.Float64s(vals)
+		q1, q3 := quantile(vals, 0.25), quantile(vals, 0.75)
+		k := p.Threshold
+		if k <= 0 {
+			k = 1.5
+		}
+		iqr := q3 - q1
+		for i := range out {
+			out[i] = valueRange{q1 - k*iqr, q3 + k*iqr, true}
+		}
+	default:
+		if len(items) < 3 {
+			return nil
+		}
+		z := p.Threshold
+		if z <= 0 {
+			z = 3
+		}
+		vals := values(items)
+		others := make([]float64, 0, len(vals)-1)
+		for i := range vals {
+			others = append(append(others[:0], vals[:i]...), vals[i+1:]...)
+			mean, sd := meanStddev(others)
+			out[i] = valueRange{mean - z*sd, mean + z*sd, sd > 0}
+		}
+	}
+	return out
+}
+
+func (m OutlierMethod) String() string {
+	switch m {
+	case OutlierZScore:
+		return "z-score"
+	case OutlierIQR:
+		return "iqr"
+	case OutlierClamp:
+		return "clamp"
+	}
+	return fmt.Sprintf("OutlierMethod(%d)", int(m))
+}
+
+func values(items []Criterion) []float64 {
+	out := make([]float64, len(items))
+	for i, it := range items {
+		out[i] = it.Value
+	}
+	return out
+}
+
+func meanStddev(v []float64) (mean, sd float64) {
+	for _, x := range v {
+		mean += x
+	}
+	mean /= float64(len(v))
+	for _, x := range v {
+		sd += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(sd / float64(len(v)))
+}
+
+// quantile interpolates linearly within sorted v.
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(i)
+	return sorted[i] + frac*(sorted[i+1]-sorted[i])
+}
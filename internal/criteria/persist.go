@@ -0,0 +1,216 @@
+package criteria
+
+// Pluggable criteria persistence
+// CriteriaStore is where criteria live between runs: looked up by learner and
+// course, written whole, and listed page by page.
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Page sizes for CriteriaStore.List.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+var ErrBadPageToken = errors.New("invalid page token")
+
+// CriteriaFilter selects criteria to list. Empty LearnerID or CourseID match
+// any. PageSize is clamped to MaxPageSize; zero means DefaultPageSize.
+type CriteriaFilter struct {
+	LearnerID string
+	CourseID  string
+	PageSize  int
+	PageToken string // NextPageToken of the previous page; empty for the first
+}
+
+// CriteriaPage is one page of a listing. NextPageToken is empty on the last
+// page.
+type CriteriaPage struct {
+	Items         []Criteria
+	NextPageToken string
+}
+
+// CriteriaStore persists criteria per (learner, course).
+//
+// Contract for implementations, DB-backed ones in particular:
+//   - Get returns ok=false and a nil error for criteria never stored; err is
+//     for the backend failing, never for a miss.
+//   - Put stores c whole, replacing what was there, and bumps its Version:
+//     the version the stored criteria had plus one. Callers that must not
+//     overwrite concurrent changes use Store.Update instead.
+//   - List orders by learner ID, then course ID, and its page tokens are
+//     opaque and stay valid while entries are added or removed: a page
+//     starts after the last entry of the previous one, so no entry present
+//     throughout a listing is returned twice or skipped. A token a store did
+//     not issue fails with ErrBadPageToken.
+//   - Stored and returned criteria are copies; callers may modify them.
+//   - Every method is safe for concurrent use and honors ctx.
+type CriteriaStore interface {
+	Get(ctx context.Context, learnerID, courseID string) (c Criteria, ok bool, err error)
+	Put(ctx context.Context, c Criteria) error
+	List(ctx context.Context, f CriteriaFilter) (CriteriaPage, error)
+}
+
+// MemoryCriteriaStore is the in-memory CriteriaStore. It keeps criteria in a
+// Store, so wrappers such as AuditedStore see every Put, and indexes their
+// keys for List.
+type MemoryCriteriaStore struct {
+	Store Store
+
+	mu   sync.RWMutex
+	keys [][2]string // sorted (learner, course) pairs held by Store
+}
+
+// NewMemoryCriteriaStore keeps criteria in s, or in a new MemoryStore when s
+// is nil. Criteria s already holds are indexed when s can list them (a
+// MemoryStore, or an AuditedStore over one); any other s must start empty.
+// From then on s must only be written through the returned store, or List
+// misses the criteria written around it.
+func NewMemoryCriteriaStore(s Store) *MemoryCriteriaStore {
+	if s == nil {
+		s = NewMemoryStore()
+	}
+	keys := storedKeys(s)
+	sort.Slice(keys, func(i, j int) bool { return keyLess(keys[i], keys[j]) })
+	return &MemoryCriteriaStore{Store: s, keys: keys}
+}
+
+// storedKeys returns the keys of the criteria in s, if it can list them.
+func storedKeys(s Store) [][2]string {
+	switch s := s.(type) {
+	case *MemoryStore:
+		return s.Keys()
+	case *AuditedStore:
+		return storedKeys(s.Store)
+	}
+	return nil
+}
+
+func (s *MemoryCriteriaStore) Get(ctx context.Context, learnerID, courseID string) (Criteria, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Criteria{}, false, err
+	}
+	c, ok := s.Store.Get(learnerID, courseID)
+	return c, ok, nil
+}
+
+// contextUpdater is implemented by stores that take the writer from the
+// context, like *AuditedStore.
+type contextUpdater interface {
+	UpdateContext(ctx context.Context, c Criteria, expectedVersion int) error
+}
+
+// Put stores c over whatever is stored, retrying the versioned write if a
+// concurrent Update gets in between. A Store with UpdateContext gets ctx, so
+// an AuditedStore records the caller as the actor.
+func (s *MemoryCriteriaStore) Put(ctx context.Context, c Criteria) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		cur, _ := s.Store.Get(c.LearnerID, c.CourseID)
+		var err error
+		if cu, ok := s.Store.(contextUpdater); ok {
+			err = cu.UpdateContext(ctx, c, cur.Version)
+		} else {
+			err = s.Store.Update(c, cur.Version)
+		}
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.index([2]string{c.LearnerID, c.CourseID})
+		return nil
+	}
+}
+
+func (s *MemoryCriteriaStore) List(ctx context.Context, f CriteriaFilter) (CriteriaPage, error) {
+	if err := ctx.Err(); err != nil {
+		return CriteriaPage{}, err
+	}
+	size := f.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	size = min(size, MaxPageSize)
+	after, err := decodePageToken(f.PageToken)
+	if err != nil {
+		return CriteriaPage{}, err
+	}
+
+	s.mu.RLock()
+	keys := s.keys
+	start := 0
+	if after != nil {
+		start = sort.Search(len(keys), func(i int) bool { return keyLess(*after, keys[i]) })
+	}
+	var page CriteriaPage
+	for _, k := range keys[start:] {
+		if f.LearnerID != "" && k[0] != f.LearnerID || f.CourseID != "" && k[1] != f.CourseID {
+			continue
+		}
+		if len(page.Items) == size {
+			page.NextPageToken = encodePageToken(page.last())
+			break
+		}
+		if c, ok := s.Store.Get(k[0], k[1]); ok {
+			page.Items = append(page.Items, c)
+		}
+	}
+	s.mu.RUnlock()
+	return page, nil
+}
+
+func (p CriteriaPage) last() [2]string {
+	c := p.Items[len(p.Items)-1]
+	return [2]string{c.LearnerID, c.CourseID}
+}
+
+// index adds k to the sorted key index.
+func (s *MemoryCriteriaStore) index(k [2]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := sort.Search(len(s.keys), func(i int) bool { return !keyLess(s.keys[i], k) })
+	if i < len(s.keys) && s.keys[i] == k {
+		return
+	}
+	s.keys = append(s.keys, [2]string{})
+	copy(s.keys[i+1:], s.keys[i:])
+	s.keys[i] = k
+}
+
+func keyLess(a, b [2]string) bool {
+	if a[0] != b[0] {
+		return a[0] < b[0]
+	}
+	return a[1] < b[1]
+}
+
+func encodePageToken(k [2]string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(k[0] + "\x00" + k[1]))
+}
+
+func decodePageToken(tok string) (*[2]string, error) {
+	if tok == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(tok)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadPageToken, err)
+	}
+	learner, course, ok := strings.Cut(string(b), "\x00")
+	if !ok {
+		return nil, ErrBadPageToken
+	}
+	return &[2]string{learner, course}, nil
+}
@@ -0,0 +1,134 @@
+package criteria
+
+// Composable aggregation stages
+
+// Stage is one step of a scoring Pipeline: it receives the criteria as left
+// by the previous stage and returns them transformed. Stages must not modify
+// the input's Items slice in place.
+type Stage interface {
+	Apply(c Criteria) (Criteria, error)
+}
+
+// StageFunc adapts a function to Stage.
+type StageFunc func(c Criteria) (Criteria, error)
+
+func (f StageFunc) Apply(c Criteria) (Criteria, error) { return f(c) }
+
+// Pipeline runs Stages in order and reduces the result to its weighted mean.
+type Pipeline struct {
+	Stages []Stage
+}
+
+// DefaultPipeline reproduces Aggregate: unify kinds (or normalize against
+// the cohort, if configured), screen outliers (if configured), apply the
+// course's weight profile (if configured), compute effective weights.
+func DefaultPipeline(opts AggregateOptions) Pipeline {
+	p := Pipeline{Stages: []Stage{UnifyKinds()}}
+	if opts.Cohort != nil {
+		p.Stages[0] = NormalizeToCohort(*opts.Cohort)
+	}
+	if opts.Outliers != nil {
+		p.Stages = append(p.Stages, OutlierStage(*opts.Outliers))
+	}
+	if opts.Profiles != nil {
+		p.Stages = append(p.Stages, ProfileStage(opts.Profiles))
+	}
+	if opts.Weigher != nil {
+		p.Stages = append(p.Stages, WeighStage(opts.Weigher, opts.Context))
+	}
+	return p
+}
+
+// Run applies every stage to c and aggregates what is left. Outliers found
+// by OutlierStage stages are reported on the Score.
+func (p Pipeline) Run(c Criteria) (Score, error) {
+	if len(c.Items) == 0 {
+		return Score{}, ErrNoCriteria
+	}
+	s := Score{LearnerID: c.LearnerID, CourseID: c.CourseID}
+	for _, st := range p.Stages {
+		var err error
+		if os, ok := st.(outlierStage); ok {
+			var found []Outlier
+			c, found, err = os.screen(c)
+			s.Outliers = append(s.Outliers, found...)
+		} else {
+			c, err = st.Apply(c)
+		}
+		if err != nil {
+			return Score{}, err
+		}
+	}
+
+	var sum float64
+	for _, it := range c.Items {
+		weight := it.Weight
+		if weight < 0 {
+			weight = 0
+		}
+		sum += it.Value * weight
+		s.Weight += weight
+	}
+	if s.Weight == 0 {
+		return s, ErrZeroWeight
+	}
+	s.Value = sum / s.Weight
+	return s, nil
+}
+
+// UnifyKinds converts items to ratios (see Criterion.Ratio), even when they
+// all share one kind.
+func UnifyKinds() Stage {
+	return StageFunc(func(c Criteria) (Criteria, error) {
+		items, err := unifyKinds(c.Items)
+		if err != nil {
+			return Criteria{}, err
+		}
+		c.Items = items
+		return c, nil
+	})
+}
+
+// outlierStage is OutlierStage's hook for reporting to Pipeline.Run.
+type outlierStage struct {
+	policy OutlierPolicy
+}
+
+// OutlierStage screens item values with p. Inside a Pipeline the outliers
+// it finds are reported on the Score; Apply alone just drops or clamps.
+func OutlierStage(p OutlierPolicy) Stage {
+	return outlierStage{policy: p}
+}
+
+func (s outlierStage) Apply(c Criteria) (Criteria, error) {
+	c, _, err := s.screen(c)
+	return c, err
+}
+
+func (s outlierStage) screen(c Criteria) (Criteria, []Outlier, error) {
+	var found []Outlier
+	c.Items, found = s.policy.apply(c.Items)
+	return c, found, nil
+}
+
+// WeighStage replaces every item's Weight with w's effective weight
+// (negative weights become zero). ctx's LearnerID and CourseID default to
+// the Criteria's.
+func WeighStage(w Weigher, ctx WeighContext) Stage {
+	return StageFunc(func(c Criteria) (Criteria, error) {
+		wctx := ctx
+		if wctx.LearnerID == "" {
+			wctx.LearnerID = c.LearnerID
+		}
+		if wctx.CourseID == "" {
+			wctx.CourseID = c.CourseID
+		}
+		items := make([]Criterion, len(c.Items))
+		for i, it := range c.Items {
+			it.Weight = max(w.Weight(it, wctx), 0)
+			items[i] = it
+		}
+		c.Items = items
+		return c, nil
+	})
+}
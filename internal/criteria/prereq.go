@@ -0,0 +1,116 @@
+package criteria
+
+// Prerequisite relationships between criteria
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrPrereqCycle = errors.New("criteria prerequisites form a cycle")
+
+// Prerequisite says a criterion can only be assessed once Key reaches
+// Threshold, in Key's own units (its Value).
+type Prerequisite struct {
+	Key       string
+	Threshold float64
+}
+
+// UnmetPrereq reports a criterion whose prerequisite is below threshold or
+// missing from the Criteria.
+type UnmetPrereq struct {
+	Key          string // the dependent criterion
+	Prerequisite string
+	Value        float64 // the prerequisite's value; zero if Missing
+	Threshold    float64
+	Missing      bool
+}
+
+// ValidatePrerequisites fails with ErrPrereqCycle if the prerequisites of c
+// form a cycle, naming one of them.
+func ValidatePrerequisites(c Criteria) error {
+	_, err := prereqOrder(c)
+	return err
+}
+
+// CheckPrerequisites returns every unmet prerequisite in c, ordered so that
+// foundational criteria come first: remediation can follow the list in
+// order. Prerequisites naming keys absent from c count as unmet. If the graph
+// has a cycle it returns nil; use ValidatePrerequisites to get the error.
+func CheckPrerequisites(c Criteria) []UnmetPrereq {
+	order, err := prereqOrder(c)
+	if err != nil {
+		return nil
+	}
+	byKey := make(map[string]Criterion, len(c.Items))
+	for _, it := range c.Items {
+		byKey[it.Key] = it
+	}
+	var out []UnmetPrereq
+	for _, i := range order {
+		it := c.Items[i]
+		for _, p := range it.Prerequisites {
+			pre, ok := byKey[p.Key]
+			switch {
+			case !ok:
+				out = append(out, UnmetPrereq{Key: it.Key, Prerequisite: p.Key, Threshold: p.Threshold, Missing: true})
+			case pre.Value < p.Threshold:
+				out = append(out, UnmetPrereq{Key: it.Key, Prerequisite: p.Key, Value: pre.Value, Threshold: p.Threshold})
+			}
+		}
+	}
+	return out
+}
+
+// prereqOrder returns item indexes in topological order, prerequisites
+// before dependents and otherwise in item order.
+func prereqOrder(c Criteria) ([]int, error) {
+	index := make(map[string]int, len(c.Items))
+	for i, it := range c.Items {
+		index[it.Key] = i
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	mark := make([]int, len(c.Items))
+	order := make([]int, 0, len(c.Items))
+	var path []string
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch mark[i] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for j, k := range path {
+				if k == c.Items[i].Key {
+					start = j
+				}
+			}
+			cycle := append(append([]string(nil), path[start:]...), c.Items[i].Key)
+			return fmt.Errorf("%w: %s", ErrPrereqCycle, strings.Join(cycle, " -> "))
+		}
+		mark[i] = visiting
+		path = append(path, c.Items[i].Key)
+		for _, p := range c.Items[i].Prerequisites {
+			if j, ok := index[p.Key]; ok {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		mark[i] = done
+		order = append(order, i)
+		return nil
+	}
+	for i := range c.Items {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
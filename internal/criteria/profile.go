@@ -0,0 +1,94 @@
+package criteria
+
+// Per-course weighting profiles
+// Courses weigh the same criteria differently; a profile carries a course's
+// weights so scoring policy lives apart from the criteria data.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+)
+
+var ErrInvalidProfile = errors.New("invalid weight profile")
+
+// WeightProfile maps criterion keys to the weight they carry in one course.
+// Keys it does not list keep their static Weight.
+type WeightProfile map[string]float64
+
+// WeightProfiles maps course IDs to their profile. Courses without one keep
+// the static weights.
+type WeightProfiles map[string]WeightProfile
+
+// Apply returns c with the weights of c.CourseID's profile, if any.
+func (p WeightProfiles) Apply(c Criteria) Criteria {
+	prof, ok := p[c.CourseID]
+	if !ok {
+		return c
+	}
+	items := make([]Criterion, len(c.Items))
+	for i, it := range c.Items {
+		if w, ok := prof[it.Key]; ok {
+			it.Weight = w
+		}
+		items[i] = it
+	}
+	c.Items = items
+	return c
+}
+
+// Validate checks that every weight is a non-negative number and that every
+// profiled key exists in the template for its course. A profile for a course
+// none of templates covers is invalid too. All problems are reported
+// together, wrapping ErrInvalidProfile.
+func (p WeightProfiles) Validate(templates []Template) error {
+	keys := make(map[string]map[string]bool, len(templates))
+	for _, t := range templates {
+		known := make(map[string]bool, len(t.Items))
+		for _, it := range t.Items {
+			known[it.Key] = true
+		}
+		keys[t.CourseID] = known
+	}
+	var errs []error
+	for _, course := range slices.Sorted(maps.Keys(p)) {
+		known, ok := keys[course]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: course %q has no template", ErrInvalidProfile, course))
+			continue
+		}
+		for _, key := range slices.Sorted(maps.Keys(p[course])) {
+			w := p[course][key]
+			if !known[key] {
+				errs = append(errs, fmt.Errorf("%w: course %q: unknown criterion %q", ErrInvalidProfile, course, key))
+			}
+			if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+				errs = append(errs, fmt.Errorf("%w: course %q: criterion %q has weight %v", ErrInvalidProfile, course, key, w))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LoadWeightProfiles reads profiles as JSON, {"course": {"key": weight}},
+// and validates them against templates.
+func LoadWeightProfiles(r io.Reader, templates []Template) (WeightProfiles, error) {
+	var p WeightProfiles
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProfile, err)
+	}
+	if err := p.Validate(templates); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ProfileStage applies p before weighting, so a Weigher sees profile weights
+// as each criterion's Weight.
+func ProfileStage(p WeightProfiles) Stage {
+	return StageFunc(func(c Criteria) (Criteria, error) { return p.Apply(c), nil })
+}
@@ -0,0 +1,81 @@
+package criteria
+
+// Sensitivity analysis: which criterion would raise the score most
+// Each criterion is pushed to full marks in turn and the criteria re-scored,
+// so weights, profiles and kind conversion count exactly as in Aggregate.
+
+import "sort"
+
+// CriterionImpact is how much the aggregate would change if one criterion
+// were at full marks.
+type CriterionImpact struct {
+	Key   string
+	Value float64 // current value
+	Full  float64 // full-marks value it was raised to
+	Score float64 // aggregate with this criterion at Full
+	Gain  float64 // Score minus the current aggregate
+}
+
+// fullMarks is the best value c can take: 100 percent, a ratio or boolean
+// of 1, or Max for counts and points. Unspecified kinds have full marks only
+// when Max is set; z-scores have none.
+func (c Criterion) fullMarks() (float64, bool) {
+	switch c.Kind {
+	case KindPercent:
+		return 100, true
+	case KindRatio, KindBoolean:
+		return 1, true
+	case KindCount, KindPoints, KindUnspecified:
+		return c.Max, c.Max > 0
+	}
+	return 0, false
+}
+
+// Sensitivity ranks c's criteria by how much maxing each out would raise its
+// plain weighted mean (Aggregate with zero options), largest gain first. It
+// returns nil when c cannot be aggregated; use Pipeline.Sensitivity with the
+// scoring configuration in use to see why, or to honor weighers and
+// profiles.
+func Sensitivity(c Criteria) []CriterionImpact {
+	impacts, err := DefaultPipeline(AggregateOptions{}).Sensitivity(c)
+	if err != nil {
+		return nil
+	}
+	return impacts
+}
+
+// Sensitivity ranks c's criteria by the gain in p's score from maxing each
+// out, largest first, ties by key. Criteria with no full marks (z-scores,
+// and counts, points or unspecified kinds without a Max), and those already
+// at them, are left out.
+func (p Pipeline) Sensitivity(c Criteria) ([]CriterionImpact, error) {
+	base, err := p.Run(c)
+	if err != nil {
+		return nil, err
+	}
+	var impacts []CriterionImpact
+	for i, it := range c.Items {
+		full, ok := it.fullMarks()
+		if !ok || it.Value >= full {
+			continue
+		}
+		maxed := c
+		maxed.Items = append([]Criterion(nil), c.Items...)
+		maxed.Items[i].Value = full
+		s, err := p.Run(maxed)
+		if err != nil {
+			return nil, err
+		}
+		impacts = append(impacts, CriterionImpact{
+			Key: it.Key, Value: it.Value, Full: full,
+			Score: s.Value, Gain: s.Value - base.Value,
+		})
+	}
+	sort.SliceStable(impacts, func(i, j int) bool {
+		if impacts[i].Gain != impacts[j].Gain {
+			return impacts[i].Gain > impacts[j].Gain
+		}
+		return impacts[i].Key < impacts[j].Key
+	})
+	return impacts, nil
+}
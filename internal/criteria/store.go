@@ -0,0 +1,79 @@
+package criteria
+
+// Versioned criteria store with optimistic concurrency
+// Writers state the version they read; a write based on a stale read fails
+// with ErrConflict instead of silently overwriting a concurrent update.
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrConflict = errors.New("criteria were updated concurrently")
+
+// Store keeps the latest Criteria per (learner, course).
+type Store interface {
+	// Get returns the stored criteria; ok is false if there are none.
+	Get(learnerID, courseID string) (c Criteria, ok bool)
+	// Update stores c if the stored version is still expectedVersion (zero
+	// for criteria not stored yet) and bumps the version. Otherwise it
+	// returns ErrConflict and the caller should Get, reapply and retry.
+	Update(c Criteria, expectedVersion int) error
+}
+
+// MemoryStore is a threadsafe in-memory Store. It stores and returns copies,
+// so callers may keep modifying what they passed in or got back.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	byID map[[2]string]Criteria
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{byID: make(map[[2]string]Criteria)}
+}
+
+func (s *MemoryStore) Get(learnerID, courseID string) (Criteria, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.byID[[2]string{learnerID, courseID}]
+	return cloneCriteria(c), ok
+}
+
+func (s *MemoryStore) Update(c Criteria, expectedVersion int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := [2]string{c.LearnerID, c.CourseID}
+	if cur := s.byID[k].Version; cur != expectedVersion {
+		return fmt.Errorf("%w: %s/%s is at version %d, not %d", ErrConflict, c.LearnerID, c.CourseID, cur, expectedVersion)
+	}
+	c = cloneCriteria(c)
+	c.Version = expectedVersion + 1
+	s.byID[k] = c
+	return nil
+}
+
+// Keys returns the (learner, course) pair of every stored criteria, in no
+// particular order.
+func (s *MemoryStore) Keys() [][2]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([][2]string, 0, len(s.byID))
+	for k := range s.byID {
+		out = append(out, k)
+	}
+	return out
+}
+
+func cloneCriteria(c Criteria) Criteria {
+	if c.Items == nil {
+		return c
+	}
+	items := make([]Criterion, len(c.Items))
+	for i, it := range c.Items {
+		it.Prerequisites = append([]Prerequisite(nil), it.Prerequisites...)
+		items[i] = it
+	}
+	c.Items = items
+	return c
+}
@@ -0,0 +1,70 @@
+package criteria
+
+// Course-level criteria templates
+// A Template defines the criteria a course scores on; per-learner values fill it in.
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrMissingValue    = errors.New("template value missing")
+	ErrUnexpectedValue = errors.New("value not in template")
+)
+
+// TemplateItem is one criterion definition. An item with a Default is
+// optional: the default is used when no value is supplied.
+type TemplateItem struct {
+	Key     string
+	Weight  float64
+	Source  string
+	Kind    Kind
+	Max     float64
+	Default *float64
+}
+
+// Template is a course's scoring definition.
+type Template struct {
+	CourseID string
+	Items    []TemplateItem
+}
+
+// Expand fills t with values, keyed by criterion key, and returns the concrete
+// Criteria in template order. LearnerID is left for the caller to set. Required
+// keys without a value and values for keys the template does not define are
+// errors; all of them are reported together.
+func Expand(t Template, values map[string]float64) (Criteria, error) {
+	c := Criteria{CourseID: t.CourseID, Items: make([]Criterion, 0, len(t.Items))}
+	var errs []error
+	known := make(map[string]bool, len(t.Items))
+	for _, it := range t.Items {
+		known[it.Key] = true
+		v, ok := values[it.Key]
+		if !ok {
+			if it.Default == nil {
+				errs = append(errs, fmt.Errorf("%w: %q", ErrMissingValue, it.Key))
+				continue
+			}
+			v = *it.Default
+		}
+		c.Items = append(c.Items, Criterion{
+			Key: it.Key, Value: v, Weight: it.Weight, Source: it.Source, Kind: it.Kind, Max: it.Max,
+		})
+	}
+	var extra []string
+	for k := range values {
+		if !known[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	for _, k := range extra {
+		errs = append(errs, fmt.Errorf("%w: %q", ErrUnexpectedValue, k))
+	}
+	if len(errs) > 0 {
+		return Criteria{}, errors.Join(errs...)
+	}
+	return c, nil
+}
@@ -0,0 +1,49 @@
+package criteria
+
+// Criterion-level access control
+// Items are tagged with a visibility level; Filter drops what a principal may not see.
+
+import "github.com/ngx-workshop/mcp-server/internal/security"
+
+// Visibility is the audience of a criterion.
+type Visibility string
+
+const (
+	VisibilityPublic     Visibility = ""           // everyone, learners included
+	VisibilityInstructor Visibility = "instructor" // course staff, e.g. behavioral notes
+	VisibilityAdmin      Visibility = "admin"      // administrators only
+)
+
+// VisibilityRoles maps each restricted level to the roles allowed to see it.
+// Levels missing from the map are visible to nobody.
+var VisibilityRoles = map[Visibility][]string{
+	VisibilityInstructor: {security.RoleInstructor, security.RoleAdmin},
+	VisibilityAdmin:      {security.RoleAdmin},
+}
+
+// Visible reports whether claims may see an item of level v. Nil claims only
+// see public items.
+func (v Visibility) Visible(claims *security.Claims) bool {
+	if v == VisibilityPublic {
+		return true
+	}
+	for _, role := range VisibilityRoles[v] {
+		if claims.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns a copy of c without the items claims may not see, so the
+// aggregate a learner gets can differ from what an instructor gets.
+func Filter(c Criteria, claims *security.Claims) Criteria {
+	out := c
+	out.Items = make([]Criterion, 0, len(c.Items))
+	for _, it := range c.Items {
+		if it.Visibility.Visible(claims) {
+			out.Items = append(out.Items, it)
+		}
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package criteria
+
+// Dynamic weighting policies
+
+// WeighContext carries what a policy needs to know about the learner and
+// course being scored.
+type WeighContext struct {
+	LearnerID string
+	CourseID  string
+	Course    map[string]any // course policy metadata, e.g. "level": "advanced"
+	Learner   map[string]any // learner metadata, e.g. "level": 2
+}
+
+// Weigher computes the effective weight of a criterion during aggregation,
+// overriding its static Weight. Negative results count as zero.
+type Weigher interface {
+	Weight(c Criterion, ctx WeighContext) float64
+}
+
+// WeigherFunc adapts a function to Weigher.
+type WeigherFunc func(c Criterion, ctx WeighContext) float64
+
+func (f WeigherFunc) Weight(c Criterion, ctx WeighContext) float64 { return f(c, ctx) }
+
+// StaticWeigher uses each criterion's own Weight field; it is the default.
+type StaticWeigher struct{}
+
+func (StaticWeigher) Weight(c Criterion, _ WeighContext) float64 { return c.Weight }
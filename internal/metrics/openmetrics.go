@@ -0,0 +1,165 @@
+package metrics
+
+// OpenMetrics text exposition and textfile export
+// Serializes registry and orchestrator counters into the OpenMetrics text
+// format, and periodically writes them to a file in the Prometheus text
+// format for node-exporter's textfile collector.
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Family is one metric with its samples. Type is "counter", "gauge" or
+// "histogram"; for counters, Name omits the "_total" suffix, which is added
+// on output.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+	Suffix string // histograms: "_bucket", "_sum" or "_count"
+}
+
+// Source produces the current metric families.
+type Source func() []Family
+
+// WriteOpenMetrics renders fams, sorted by name, terminated by "# EOF".
+func WriteOpenMetrics(w io.Writer, fams []Family) error {
+	return writeFamilies(w, fams, true)
+}
+
+// WriteText renders fams, sorted by name, in the Prometheus text format
+// 0.0.4. That is the format node-exporter's textfile collector parses: it
+// declares a counter under its "_total" sample name and has no "# EOF".
+func WriteText(w io.Writer, fams []Family) error {
+	return writeFamilies(w, fams, false)
+}
+
+func writeFamilies(w io.Writer, fams []Family, openMetrics bool) error {
+	sort.Slice(fams, func(i, j int) bool { return fams[i].Name < fams[j].Name })
+	bw := bufio.NewWriter(w)
+	for _, f := range fams {
+		name := f.Name
+		if f.Type == "counter" {
+			name += "_total"
+		}
+		family, help := name, escapeTextHelp
+		if openMetrics {
+			family, help = f.Name, escapeHelp
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", family, f.Type)
+		if f.Help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", family, help(f.Help))
+		}
+		for _, s := range f.Samples {
+			fmt.Fprintf(bw, "%s%s%s %g\n", name, s.Suffix, labels(s.Labels), s.Value)
+		}
+	}
+	if openMetrics {
+		fmt.Fprintln(bw, "# EOF")
+	}
+	return bw.Flush()
+}
+
+// FileExporter writes every Source to Path each Interval, in the format
+// WriteText produces. Each write goes to a temporary file in the same
+// directory that is then renamed over Path, so readers never observe a
+// partial file.
+type FileExporter struct {
+	Path     string
+	Interval time.Duration // defaults to 15s
+	Sources  []Source
+}
+
+// Run exports immediately and then on every tick until ctx is done.
+func (e *FileExporter) Run(ctx context.Context) error {
+	interval := e.Interval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	if err := e.WriteOnce(); err != nil {
+		return err
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+			if err := e.WriteOnce(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// WriteOnce collects all sources and atomically replaces Path.
+func (e *FileExporter) WriteOnce() error {
+	var fams []Family
+	for _, src := range e.Sources {
+		fams = append(fams, src()...)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(e.Path), "."+filepath.Base(e.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if err := WriteText(tmp, fams); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), e.Path)
+}
+
+func labels(m map[string]string) string {
+	if len(m) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + `="` + escapeLabel(m[k]) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	// Text format 0.0.4 escapes only backslash and newline in HELP;
+	// OpenMetrics escapes quotes too.
+	textHelpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string    { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string     { return labelEscaper.Replace(s) }
+func escapeTextHelp(s string) string { return textHelpEscaper.Replace(s) }
@@ -0,0 +1,91 @@
+package metrics
+
+// Sources for the registry and orchestrator counters
+
+import (
+	"maps"
+	"strconv"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/orchestrator"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// RegistrySource exports agent selection and timeout budget counters.
+func RegistrySource(r *agents.Registry) Source {
+	return func() []Family {
+		st := r.Stats()
+		sel := Family{Name: "mcp_agent_selections", Help: "Times an agent was selected for a task type.", Type: "counter"}
+		for name, byType := range st.Selected {
+			for t, n := range byType {
+				sel.Samples = append(sel.Samples, Sample{Labels: map[string]string{"agent": name, "task_type": t}, Value: float64(n)})
+			}
+		}
+		miss := Family{Name: "mcp_agent_selection_misses", Help: "Selections that found no eligible agent.", Type: "counter"}
+		for t, n := range st.Misses {
+			miss.Samples = append(miss.Samples, Sample{Labels: map[string]string{"task_type": t}, Value: float64(n)})
+		}
+		timed := Family{Name: "mcp_agent_timed_executions", Help: "Executions that ran under a timeout.", Type: "counter"}
+		slow := Family{Name: "mcp_agent_slow_executions", Help: "Timed executions that went past the soft deadline.", Type: "counter"}
+		for name, b := range st.Timing {
+			labels := map[string]string{"agent": name}
+			timed.Samples = append(timed.Samples, Sample{Labels: labels, Value: float64(b.Executions)})
+			slow.Samples = append(slow.Samples, Sample{Labels: labels, Value: float64(b.Slow)})
+		}
+		return []Family{sel, miss, timed, slow}
+	}
+}
+
+// OrchestratorSource exports execution counters.
+func OrchestratorSource(o *orchestrator.Orchestrator) Source {
+	return func() []Family {
+		st := o.Stats()
+		exec := Family{Name: "mcp_task_executions", Help: "Finished task executions by type and result status.", Type: "counter"}
+		for t, byStatus := range st.Executions {
+			for status, n := range byStatus {
+				exec.Samples = append(exec.Samples, Sample{Labels: map[string]string{"task_type": t, "status": status}, Value: float64(n)})
+			}
+		}
+		secs := Family{Name: "mcp_task_execution_seconds", Help: "Total time spent executing tasks by type.", Type: "counter"}
+		for t, s := range st.Seconds {
+			secs.Samples = append(secs.Samples, Sample{Labels: map[string]string{"task_type": t}, Value: s})
+		}
+		return []Family{exec, secs}
+	}
+}
+
+// QueueSource exports the queue's wait and service time histograms.
+func QueueSource(q *tasks.MemoryQueue) Source {
+	return func() []Family {
+		st := q.Stats()
+		wait := Family{Name: "mcp_queue_wait_seconds", Help: "Time tasks spent queued before a worker took them.", Type: "histogram"}
+		for t, h := range st.Wait {
+			wait.Samples = append(wait.Samples, histogramSamples(map[string]string{"task_type": t}, h)...)
+		}
+		service := Family{Name: "mcp_queue_service_seconds", Help: "Time from dequeue to ack.", Type: "histogram"}
+		for t, h := range st.Service {
+			service.Samples = append(service.Samples, histogramSamples(map[string]string{"task_type": t}, h)...)
+		}
+		return []Family{wait, service}
+	}
+}
+
+// histogramSamples renders h as cumulative buckets plus sum and count.
+func histogramSamples(labels map[string]string, h tasks.Histogram) []Sample {
+	out := make([]Sample, 0, len(h.Counts)+2)
+	var cum uint64
+	for i, n := range h.Counts {
+		cum += n
+		le := "+Inf"
+		if i < len(h.Bounds) {
+			le = strconv.FormatFloat(h.Bounds[i].Seconds(), 'g', -1, 64)
+		}
+		l := maps.Clone(labels)
+		l["le"] = le
+		out = append(out, Sample{Labels: l, Value: float64(cum), Suffix: "_bucket"})
+	}
+	return append(out,
+		Sample{Labels: labels, Value: h.Sum.Seconds(), Suffix: "_sum"},
+		Sample{Labels: labels, Value: float64(h.Count), Suffix: "_count"},
+	)
+}
@@ -3,3 +3,48 @@ package middleware
 // AuthN/Z gate for routes
 // This file implements authentication and authorization middleware
 // to secure routes and validate user permissions before processing requests
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ngx-workshop/mcp-server/internal/security"
+)
+
+// Authz authenticates every request with auth and, if roles are given,
+// requires the principal to hold at least one of them. The resolved claims are
+// stored in the request context (see security.ClaimsFromContext).
+func Authz(auth security.Authenticator, roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := auth.Authenticate(r)
+			if err != nil {
+				deny(w, http.StatusUnauthorized, "unauthenticated", err)
+				return
+			}
+			if len(roles) > 0 && !hasAny(claims, roles) {
+				deny(w, http.StatusForbidden, "forbidden", security.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(security.WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+func hasAny(c *security.Claims, roles []string) bool {
+	for _, r := range roles {
+		if c.HasRole(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// deny writes the same error envelope the API handlers use.
+func deny(w http.ResponseWriter, status int, code string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{"code": code, "message": err.Error()},
+	})
+}
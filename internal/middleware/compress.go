@@ -0,0 +1,109 @@
+package middleware
+
+// Gzip content-encoding for requests and responses
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Gzip inflates request bodies sent with "Content-Encoding: gzip" and
+// compresses responses of at least threshold bytes for clients that send
+// "Accept-Encoding: gzip". Smaller responses go out uncompressed. Responses
+// are buffered so their size is known before the headers are written.
+func Gzip(threshold int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+				zr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					deny(w, http.StatusBadRequest, "invalid_encoding", err)
+					return
+				}
+				defer zr.Close()
+				r.Body = zr
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+				r.ContentLength = -1
+			}
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			bw := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(bw, r)
+			bw.flush(threshold)
+		})
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc, q, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(enc), "gzip") {
+			return strings.TrimSpace(q) != "q=0"
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds the response until the handler returns. A handler
+// that flushes (e.g. a server-sent event stream) switches it to streaming the
+// response uncompressed.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status    int
+	buf       bytes.Buffer
+	streaming bool
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	if b.streaming {
+		return
+	}
+	b.status = status
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	if b.streaming {
+		return b.ResponseWriter.Write(p)
+	}
+	return b.buf.Write(p)
+}
+
+func (b *bufferedWriter) Flush() {
+	if !b.streaming {
+		b.streaming = true
+		b.ResponseWriter.WriteHeader(b.status)
+		b.ResponseWriter.Write(b.buf.Bytes())
+		b.buf.Reset()
+	}
+	if f, ok := b.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (b *bufferedWriter) flush(threshold int) {
+	if b.streaming {
+		return
+	}
+	h := b.Header()
+	if b.buf.Len() < threshold || h.Get("Content-Encoding") != "" {
+		h.Set("Content-Length", strconv.Itoa(b.buf.Len()))
+		b.ResponseWriter.WriteHeader(b.status)
+		b.ResponseWriter.Write(b.buf.Bytes())
+		return
+	}
+	var zbuf bytes.Buffer
+	zw := gzip.NewWriter(&zbuf)
+	zw.Write(b.buf.Bytes())
+	zw.Close()
+	h.Set("Content-Encoding", "gzip")
+	h.Set("Content-Length", strconv.Itoa(zbuf.Len()))
+	b.ResponseWriter.WriteHeader(b.status)
+	b.ResponseWriter.Write(zbuf.Bytes())
+}
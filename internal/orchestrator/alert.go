@@ -0,0 +1,259 @@
+package orchestrator
+
+// Paging on-call for conditions the system cannot recover from by itself
+// The orchestrator raises alerts when a critical task type has no agent to
+// run on and when dead-lettered tasks pile up. Repeats of the same alert are
+// debounced so an outage pages once, not once per task.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+)
+
+// DefaultAlertDebounce is how long a repeat of the same alert is suppressed
+// when AlertPolicy.Debounce is zero.
+const DefaultAlertDebounce = 5 * time.Minute
+
+type AlertKind string
+
+const (
+	AlertNoAgent     AlertKind = "no_capable_agent"      // TaskType has no healthy agent
+	AlertDeadLetters AlertKind = "dead_letter_threshold" // Count dead letters, at least the threshold
+)
+
+type Alert struct {
+	Kind     AlertKind `json:"kind"`
+	TaskType string    `json:"taskType,omitempty"`
+	Count    int       `json:"count,omitempty"`
+	Summary  string    `json:"summary"`
+	At       time.Time `json:"at"`
+}
+
+// Alerter delivers alerts, e.g. to a pager. Alert is called from worker
+// goroutines and must be safe for concurrent use.
+type Alerter interface {
+	Alert(ctx context.Context, a Alert) error
+}
+
+// NopAlerter drops every alert.
+type NopAlerter struct{}
+
+func (NopAlerter) Alert(context.Context, Alert) error { return nil }
+
+// DeadLetterCounter is implemented by dead-letter stores that can report
+// their size, like *tasks.MemoryPoisonStore.
+type DeadLetterCounter interface {
+	Len() int
+}
+
+// AlertPolicy says which conditions page and how often.
+type AlertPolicy struct {
+	// CriticalTypes are the task types for which having no healthy capable
+	// agent is an alert, both when a task of the type fails with ErrNoAgent
+	// and when CheckAlerts finds none.
+	CriticalTypes []string
+
+	// DeadLetters is checked by CheckAlerts; reaching DeadLetterThreshold
+	// tasks is an alert. A zero threshold disables the check.
+	DeadLetters         DeadLetterCounter
+	DeadLetterThreshold int
+
+	// Debounce suppresses repeats of an alert (same kind and task type) for
+	// this long; zero means DefaultAlertDebounce.
+	Debounce time.Duration
+}
+
+type alertState struct {
+	mu   sync.Mutex
+	last map[string]time.Time // kind + task type -> last delivery
+}
+
+// routingDescriber is implemented by registries that can list their agents'
+// health and task types, like *agents.Registry.
+type routingDescriber interface {
+	Describe() []agents.AgentRouting
+}
+
+// CheckAlerts evaluates the conditions in Alerts once and raises those that
+// hold. Delivery errors are joined into the result; debounced alerts are not
+// errors.
+func (o *Orchestrator) CheckAlerts(ctx context.Context) error {
+	if o.Alerter == nil {
+		return nil
+	}
+	var errs []error
+	if rd, ok := o.Registry.(routingDescriber); ok && len(o.Alerts.CriticalTypes) > 0 {
+		healthy := make(map[string]int)
+		for _, row := range rd.Describe() {
+			if row.Healthy {
+				for _, t := range row.TaskTypes {
+					healthy[t]++
+				}
+			}
+		}
+		for _, t := range o.Alerts.CriticalTypes {
+			if healthy[t] == 0 {
+				errs = append(errs, o.raise(ctx, noAgentAlert(t)))
+			}
+		}
+	}
+	if dl := o.Alerts.DeadLetters; dl != nil && o.Alerts.DeadLetterThreshold > 0 {
+		if n := dl.Len(); n >= o.Alerts.DeadLetterThreshold {
+			errs = append(errs, o.raise(ctx, Alert{
+				Kind: AlertDeadLetters, Count: n,
+				Summary: fmt.Sprintf("%d dead-lettered tasks (threshold %d)", n, o.Alerts.DeadLetterThreshold),
+			}))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WatchAlerts runs CheckAlerts every interval until ctx is done. Delivery
+// errors do not stop it.
+func (o *Orchestrator) WatchAlerts(ctx context.Context, interval time.Duration) error {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+			o.CheckAlerts(ctx)
+		}
+	}
+}
+
+func noAgentAlert(taskType string) Alert {
+	return Alert{Kind: AlertNoAgent, TaskType: taskType, Summary: "no healthy agent can handle critical task type " + taskType}
+}
+
+// alertNoAgent pages, in the background, when a critical task found no
+// agent. Selections cut short by ctx ending do not count.
+func (o *Orchestrator) alertNoAgent(ctx context.Context, taskType string) {
+	if o.Alerter != nil && ctx.Err() == nil && slices.Contains(o.Alerts.CriticalTypes, taskType) {
+		go o.raise(context.WithoutCancel(ctx), noAgentAlert(taskType))
+	}
+}
+
+// raise delivers a unless the same alert went out within the debounce
+// window.
+func (o *Orchestrator) raise(ctx context.Context, a Alert) error {
+	if a.At.IsZero() {
+		a.At = time.Now()
+	}
+	window := o.Alerts.Debounce
+	if window <= 0 {
+		window = DefaultAlertDebounce
+	}
+	key := string(a.Kind) + "\x00" + a.TaskType
+	s := &o.alerts
+	s.mu.Lock()
+	if last, ok := s.last[key]; ok && a.At.Sub(last) < window {
+		s.mu.Unlock()
+		return nil
+	}
+	if s.last == nil {
+		s.last = make(map[string]time.Time)
+	}
+	s.last[key] = a.At
+	s.mu.Unlock()
+	err := o.Alerter.Alert(ctx, a)
+	if err != nil {
+		// Undelivered: let the next occurrence try again.
+		s.mu.Lock()
+		if s.last[key].Equal(a.At) {
+			delete(s.last, key)
+		}
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// WebhookFormat is the body a WebhookAlerter posts.
+type WebhookFormat int
+
+const (
+	WebhookJSON      WebhookFormat = iota // the Alert as JSON
+	WebhookSlack                          // a Slack incoming-webhook message
+	WebhookPagerDuty                      // a PagerDuty Events API v2 trigger
+)
+
+// DefaultWebhookTimeout bounds one WebhookAlerter post when Timeout is unset.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookAlerter posts alerts to an HTTP endpoint.
+type WebhookAlerter struct {
+	URL     string
+	Format  WebhookFormat
+	Client  *http.Client  // defaults to http.DefaultClient
+	Timeout time.Duration // per post; defaults to DefaultWebhookTimeout
+
+	// RoutingKey is the PagerDuty integration key; Source names this
+	// service in PagerDuty events and defaults to "mcp-server".
+	RoutingKey string
+	Source     string
+}
+
+func (w *WebhookAlerter) Alert(ctx context.Context, a Alert) error {
+	var body any = a
+	switch w.Format {
+	case WebhookSlack:
+		body = map[string]string{"text": fmt.Sprintf(":rotating_light: [%s] %s", a.Kind, a.Summary)}
+	case WebhookPagerDuty:
+		source := w.Source
+		if source == "" {
+			source = "mcp-server"
+		}
+		body = map[string]any{
+			"routing_key":  w.RoutingKey,
+			"event_action": "trigger",
+			"dedup_key":    string(a.Kind) + ":" + a.TaskType,
+			"payload": map[string]any{
+				"summary":   a.Summary,
+				"source":    source,
+				"severity":  "critical",
+				"timestamp": a.At.Format(time.RFC3339),
+				"custom_details": map[string]any{
+					"kind": a.Kind, "taskType": a.TaskType, "count": a.Count,
+				},
+			},
+		}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alert webhook: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package orchestrator
+
+// Anti-affinity: spread a run's consecutive tasks across agents
+
+import (
+	"sync"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// spreadTable remembers the agent each active run last used.
+type spreadTable struct {
+	mu   sync.Mutex
+	last map[string]string // run ID -> agent name
+}
+
+func (s *spreadTable) get(runID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last[runID]
+}
+
+func (s *spreadTable) set(runID, agent string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		s.last = make(map[string]string)
+	}
+	s.last[runID] = agent
+}
+
+func (s *spreadTable) forget(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.last, runID)
+}
+
+// spread swaps a, the normal choice for t, for another capable agent when
+// AntiAffinity is on and a also ran the previous task of t's run. The
+// alternative must accept t's payload (see agents.PayloadMatcher); without
+// one, a is kept. Tasks outside a run are never swapped.
+func (o *Orchestrator) spread(t tasks.Task, a agents.Agent) agents.Agent {
+	if !o.AntiAffinity {
+		return a
+	}
+	runID := o.runOf(t.ID)
+	if runID == "" {
+		return a
+	}
+	defer func() { o.spreads.set(runID, a.Name()) }()
+	prev := o.spreads.get(runID)
+	if prev != a.Name() {
+		return a
+	}
+	es, ok := o.Registry.(excludingSelector)
+	if !ok {
+		return a
+	}
+	alt, ok := es.SelectExcluding(t.Type, prev)
+	if !ok {
+		return a
+	}
+	if !acceptsPayload(alt, t) {
+		return a
+	}
+	a = alt // for the deferred set
+	return a
+}
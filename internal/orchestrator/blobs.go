@@ -0,0 +1,153 @@
+package orchestrator
+
+// Large payload offloading to a blob store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// BlobRefKey marks an offloaded payload field: the field's value is replaced
+// by map[string]any{BlobRefKey: ref}.
+const BlobRefKey = "$blobRef"
+
+// DefaultOffloadThreshold is the encoded field size from which PayloadOffload
+// moves a field out of line when Threshold is zero.
+const DefaultOffloadThreshold = 64 << 10
+
+// PayloadOffload keeps large payload fields out of the queue. Before a task
+// is enqueued, every top-level payload field encoding to at least Threshold
+// bytes is written to Store and replaced by a reference; before the agent
+// runs, references are resolved again. Fields are encoded with
+// tasks.EncodeTyped, so their Go types survive.
+type PayloadOffload struct {
+	Store     tasks.BlobStore
+	Threshold int // bytes; zero means DefaultOffloadThreshold
+}
+
+// offload returns t with large fields replaced by references, plus the refs
+// it created so a failed enqueue can remove them.
+func (o *Orchestrator) offload(ctx context.Context, t tasks.Task) (tasks.Task, []string, error) {
+	po := o.Offload
+	if po == nil || len(t.Payload) == 0 {
+		return t, nil, nil
+	}
+	limit := po.Threshold
+	if limit <= 0 {
+		limit = DefaultOffloadThreshold
+	}
+	var out map[string]any
+	var refs []string
+	for k, v := range t.Payload {
+		b, err := tasks.EncodeTyped(map[string]any{"v": v})
+		if err != nil {
+			o.discardBlobs(ctx, refs)
+			return t, nil, fmt.Errorf("offload %s: %w", k, err)
+		}
+		if len(b) < limit {
+			continue
+		}
+		ref, err := po.Store.Put(ctx, b)
+		if err != nil {
+			o.discardBlobs(ctx, refs)
+			return t, nil, fmt.Errorf("offload %s: %w", k, err)
+		}
+		if out == nil {
+			out = cloneMap(t.Payload)
+		}
+		out[k] = map[string]any{BlobRefKey: ref}
+		refs = append(refs, ref)
+	}
+	if out != nil {
+		t.Payload = out
+	}
+	return t, refs, nil
+}
+
+// rehydrate resolves the blob references in t's payload.
+func (o *Orchestrator) rehydrate(ctx context.Context, t tasks.Task) (tasks.Task, error) {
+	if o.Offload == nil {
+		return t, nil
+	}
+	var out map[string]any
+	for k, v := range t.Payload {
+		ref, ok := blobRef(v)
+		if !ok {
+			continue
+		}
+		b, err := o.Offload.Store.Get(ctx, ref)
+		if err != nil {
+			return t, fmt.Errorf("rehydrate %s: %w", k, err)
+		}
+		m, err := tasks.DecodeTyped(b)
+		if err != nil {
+			return t, fmt.Errorf("rehydrate %s: %w", k, err)
+		}
+		if out == nil {
+			out = cloneMap(t.Payload)
+		}
+		out[k] = m["v"]
+	}
+	if out != nil {
+		t.Payload = out
+	}
+	return t, nil
+}
+
+func blobRef(v any) (string, bool) {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	ref, ok := m[BlobRefKey].(string)
+	return ref, ok
+}
+
+func (o *Orchestrator) discardBlobs(ctx context.Context, refs []string) {
+	for _, ref := range refs {
+		o.Offload.Store.Delete(ctx, ref)
+	}
+}
+
+// releaseBlobs deletes the blobs t's offloaded fields refer to, once t can
+// no longer be redelivered or requeued.
+func (o *Orchestrator) releaseBlobs(ctx context.Context, t tasks.Task) {
+	if o.Offload == nil {
+		return
+	}
+	var refs []string
+	for _, v := range t.Payload {
+		if ref, ok := blobRef(v); ok {
+			refs = append(refs, ref)
+		}
+	}
+	o.discardBlobs(ctx, refs)
+}
+
+// enqueue registers t with its group, offloads its large fields and enqueues
+// it, undoing both if the queue rejects the task.
+func (o *Orchestrator) enqueue(ctx context.Context, tp *tasks.Task) error {
+	if err := o.inheritRun(tp); err != nil {
+		return err
+	}
+	if err := o.runEnqueueHooks(ctx, tp); err != nil {
+		return err
+	}
+	t := *tp
+	if err := o.joinGroup(t); err != nil {
+		return err
+	}
+	t, refs, err := o.offload(ctx, t)
+	if err != nil {
+		o.leaveGroup(t)
+		return err
+	}
+	if err := o.enqueueEvicting(ctx, t); err != nil {
+		o.discardBlobs(ctx, refs)
+		o.leaveGroup(t)
+		return err
+	}
+	return nil
+}
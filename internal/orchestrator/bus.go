@@ -0,0 +1,124 @@
+package orchestrator
+
+// In-process pub/sub for task lifecycle events
+// Unlike webhooks, subscribers live in the same process; each gets its own
+// bounded buffer so a slow one never blocks publishers or other subscribers.
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// TopicAll subscribes to every topic. The orchestrator publishes each event
+// under its Kind, e.g. "finished".
+const TopicAll = "*"
+
+// DefaultBusBuffer is the per-subscriber buffer when EventBus.Buffer is zero.
+const DefaultBusBuffer = 64
+
+// DropPolicy decides which event a full subscriber loses.
+type DropPolicy int
+
+const (
+	DropNewest DropPolicy = iota // the event being published
+	DropOldest                   // the oldest buffered event, making room
+)
+
+// EventBus is a topic-based in-process event bus. It is safe for concurrent
+// use; Publish never blocks on subscribers.
+type EventBus struct {
+	Buffer int // per-subscriber; zero means DefaultBusBuffer
+	Drop   DropPolicy
+
+	mu      sync.RWMutex
+	subs    map[string][]*subscriber
+	dropped atomic.Uint64
+}
+
+type subscriber struct {
+	topic string
+	ch    chan Event
+}
+
+// NewEventBus creates a bus with the given per-subscriber buffer and drop
+// policy.
+func NewEventBus(buffer int, drop DropPolicy) *EventBus {
+	return &EventBus{Buffer: buffer, Drop: drop}
+}
+
+// Subscribe returns a channel receiving every event later published to
+// topic (or to any topic, for TopicAll), in publish order. Events that
+// arrive while its buffer is full are dropped as the bus's DropPolicy says.
+// Pass the channel to Unsubscribe when done.
+func (b *EventBus) Subscribe(topic string) <-chan Event {
+	n := b.Buffer
+	if n <= 0 {
+		n = DefaultBusBuffer
+	}
+	s := &subscriber{topic: topic, ch: make(chan Event, n)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[string][]*subscriber)
+	}
+	b.subs[topic] = append(b.subs[topic], s)
+	return s.ch
+}
+
+// Unsubscribe stops deliveries to ch and closes it. Unknown channels are
+// ignored.
+func (b *EventBus) Unsubscribe(ch <-chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for topic, list := range b.subs {
+		for i, s := range list {
+			if s.ch != ch {
+				continue
+			}
+			list = append(list[:i], list[i+1:]...)
+			if len(list) == 0 {
+				delete(b.subs, topic)
+			} else {
+				b.subs[topic] = list
+			}
+			close(s.ch)
+			return
+		}
+	}
+}
+
+// Publish delivers e to the subscribers of topic and of TopicAll.
+func (b *EventBus) Publish(topic string, e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subs[topic] {
+		b.deliver(s, e)
+	}
+	if topic != TopicAll {
+		for _, s := range b.subs[TopicAll] {
+			b.deliver(s, e)
+		}
+	}
+}
+
+// Dropped returns how many deliveries full subscribers have lost.
+func (b *EventBus) Dropped() uint64 { return b.dropped.Load() }
+
+func (b *EventBus) deliver(s *subscriber, e Event) {
+	for {
+		select {
+		case s.ch <- e:
+			return
+		default:
+		}
+		if b.Drop != DropOldest {
+			b.dropped.Add(1)
+			return
+		}
+		select {
+		case <-s.ch:
+			b.dropped.Add(1)
+		default: // the subscriber caught up meanwhile
+		}
+	}
+}
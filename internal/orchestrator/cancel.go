@@ -0,0 +1,88 @@
+package orchestrator
+
+// Cancellation reasons
+// Tells an operator stop, a fail-fast, a timeout and a shutdown apart on the
+// results and states of the tasks they cut short.
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// Cancelled returns a cancellation cause carrying reason, for
+// context.WithCancelCause. Cancelling a Run or Serve context with it records
+// reason on every task cut short, e.g. tasks.CancelFailFast when a caller
+// abandons sibling work after one task failed:
+//
+//	ctx, cancel := context.WithCancelCause(ctx)
+//	...
+//	cancel(orchestrator.Cancelled(tasks.CancelFailFast))
+//
+// A context that ends without such a cause counts as a timeout when its
+// deadline passed, else as CancelOperator under Run and CancelShutdown under
+// Serve. The orchestrator records CancelFailFast itself for the losing
+// execution of a hedge and for a phase abandoned after one of its tasks
+// could not be enqueued.
+func Cancelled(reason tasks.CancelReason) error {
+	return &cancelCause{reason: reason}
+}
+
+type cancelCause struct{ reason tasks.CancelReason }
+
+func (c *cancelCause) Error() string { return "cancelled: " + c.reason.String() }
+
+// CancelReasonOf returns the reason carried by a Cancelled error in err's
+// chain, or CancelNone.
+func CancelReasonOf(err error) tasks.CancelReason {
+	var c *cancelCause
+	if errors.As(err, &c) {
+		return c.reason
+	}
+	return tasks.CancelNone
+}
+
+type cancelReasonKey struct{}
+
+// withCancelReason makes reason the default for ctx ending without a
+// Cancelled cause, unless an outer caller already set one.
+func withCancelReason(ctx context.Context, reason tasks.CancelReason) context.Context {
+	if _, ok := ctx.Value(cancelReasonKey{}).(tasks.CancelReason); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, cancelReasonKey{}, reason)
+}
+
+// reasonFor returns why the done ctx ended.
+func reasonFor(ctx context.Context) tasks.CancelReason {
+	cause := context.Cause(ctx)
+	if r := CancelReasonOf(cause); r != tasks.CancelNone {
+		return r
+	}
+	if errors.Is(cause, ErrRunTimeout) || errors.Is(cause, context.DeadlineExceeded) {
+		return tasks.CancelTimeout
+	}
+	if r, ok := ctx.Value(cancelReasonKey{}).(tasks.CancelReason); ok {
+		return r
+	}
+	return tasks.CancelOperator
+}
+
+// cancelReason returns why res was cut short, or CancelNone when it failed on
+// its own or did not fail. An execution that ran into TaskTimeout while ctx
+// was still live counts as a timeout.
+func cancelReason(ctx context.Context, res tasks.Result) tasks.CancelReason {
+	switch {
+	case res.Status != agents.StatusFailed:
+		return tasks.CancelNone
+	case res.CancelReason != tasks.CancelNone:
+		return res.CancelReason
+	case ctx.Err() != nil:
+		return reasonFor(ctx)
+	case errors.Is(res.Err, context.DeadlineExceeded):
+		return tasks.CancelTimeout
+	}
+	return tasks.CancelNone
+}
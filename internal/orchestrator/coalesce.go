@@ -0,0 +1,78 @@
+package orchestrator
+
+// In-flight execution coalescing at the agent boundary
+// Queue dedup only sees tasks inside its window; a fan-out flow can still
+// hand two identical sub-tasks to workers at once. With CoalesceInflight
+// the second one waits for the first's execution instead of running again.
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// flight is one execution other tasks with the same key are waiting on.
+type flight struct {
+	leader string // task ID that is executing
+	done   chan struct{}
+	res    tasks.Result
+}
+
+type flightTable struct {
+	mu      sync.Mutex
+	flights map[string]*flight // task type + DedupKey -> running execution
+}
+
+// executeCoalesced runs t, or, if a task of the same type and DedupKey is
+// executing already, waits for it and returns a copy of its result under
+// t's ID. A waiter whose leader was cancelled while the waiter's own context
+// is still live executes t itself.
+func (o *Orchestrator) executeCoalesced(ctx context.Context, t tasks.Task) tasks.Result {
+	if !o.CoalesceInflight || t.DedupKey == "" {
+		return o.execute(ctx, t)
+	}
+	key := t.Type + "\x00" + t.DedupKey
+	ft := &o.flights
+	ft.mu.Lock()
+	if f, ok := ft.flights[key]; ok {
+		ft.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return failed(t.ID, ctx.Err())
+		}
+		cancelled := errors.Is(f.res.Err, context.Canceled) || errors.Is(f.res.Err, context.DeadlineExceeded)
+		if cancelled && ctx.Err() == nil {
+			return o.execute(ctx, t)
+		}
+		o.emit(Event{Kind: EventCoalesced, RunID: o.runOf(t.ID), TaskID: t.ID, TaskType: t.Type, From: f.leader, Status: f.res.Status})
+		res := f.res
+		res.TaskID = t.ID
+		if res.Output != nil {
+			res.Output = cloneMap(res.Output)
+		}
+		return res
+	}
+	if ft.flights == nil {
+		ft.flights = make(map[string]*flight)
+	}
+	f := &flight{leader: t.ID, done: make(chan struct{})}
+	ft.flights[key] = f
+	ft.mu.Unlock()
+
+	defer func() {
+		ft.mu.Lock()
+		delete(ft.flights, key)
+		ft.mu.Unlock()
+		close(f.done)
+	}()
+	f.res = o.execute(ctx, t)
+	if f.res.Output != nil {
+		res := f.res
+		res.Output = cloneMap(res.Output) // waiters must not see the leader's transforms
+		return res
+	}
+	return f.res
+}
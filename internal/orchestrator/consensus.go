@@ -0,0 +1,213 @@
+package orchestrator
+
+// Consensus execution: K agents grade the same task, a reconciler decides
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// Output keys a needs-review consensus result carries.
+const (
+	ReviewKey = "$needsReview" // true when a human should look at the result
+	VotesKey  = "$votes"       // []any of {"agent", "status", "output"}, one per vote
+)
+
+// ConsensusPolicy runs every task of a type on Agents distinct agents, all of
+// them regardless of outcome, and reconciles their results.
+type ConsensusPolicy struct {
+	Agents    int
+	Reconcile Reconciler // defaults to FlagDisagreement(ScoreKey, 0)
+}
+
+// ScoreKey is the Output field the default reconciler compares.
+const ScoreKey = "score"
+
+// Vote is one agent's result in a consensus execution.
+type Vote struct {
+	Agent  string
+	Result tasks.Result
+}
+
+// Reconciler merges the successful votes of a consensus execution into one
+// result. Returning StatusPartial marks it for review. It is only called with
+// at least one vote.
+type Reconciler interface {
+	Reconcile(t tasks.Task, votes []Vote) tasks.Result
+}
+
+// ReconcilerFunc adapts a function to Reconciler.
+type ReconcilerFunc func(t tasks.Task, votes []Vote) tasks.Result
+
+func (f ReconcilerFunc) Reconcile(t tasks.Task, votes []Vote) tasks.Result { return f(t, votes) }
+
+// MajorityVote takes the output of the votes whose field value more than half
+// of them share. Without such a majority the result needs review.
+func MajorityVote(field string) Reconciler {
+	return ReconcilerFunc(func(t tasks.Task, votes []Vote) tasks.Result {
+		best, bestN := 0, 0
+		for i, v := range votes {
+			n := 0
+			for _, w := range votes {
+				if reflect.DeepEqual(v.Result.Output[field], w.Result.Output[field]) {
+					n++
+				}
+			}
+			if n > bestN {
+				best, bestN = i, n
+			}
+		}
+		res := votes[best].Result
+		if bestN*2 <= len(votes) {
+			return needsReview(t, votes, fmt.Errorf("no majority on %s", field))
+		}
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusOK, Output: res.Output}
+	})
+}
+
+// Average sets field to the mean of the votes' numeric values, on a copy of
+// the first vote's output. A spread (max - min) above maxSpread, or a vote
+// without a number, needs review; a negative maxSpread never flags.
+func Average(field string, maxSpread float64) Reconciler {
+	return ReconcilerFunc(func(t tasks.Task, votes []Vote) tasks.Result {
+		lo, hi, sum, err := spread(votes, field)
+		if err != nil {
+			return needsReview(t, votes, err)
+		}
+		if maxSpread >= 0 && hi-lo > maxSpread {
+			return needsReview(t, votes, disagreement(field, lo, hi, maxSpread))
+		}
+		out := cloneMap(votes[0].Result.Output)
+		out[field] = sum / float64(len(votes))
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusOK, Output: out}
+	})
+}
+
+// FlagDisagreement keeps the first vote's output as long as every vote's
+// numeric field lies within maxSpread of the others, and asks for review
+// otherwise.
+func FlagDisagreement(field string, maxSpread float64) Reconciler {
+	return ReconcilerFunc(func(t tasks.Task, votes []Vote) tasks.Result {
+		lo, hi, _, err := spread(votes, field)
+		if err != nil {
+			return needsReview(t, votes, err)
+		}
+		if hi-lo > maxSpread {
+			return needsReview(t, votes, disagreement(field, lo, hi, maxSpread))
+		}
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusOK, Output: votes[0].Result.Output}
+	})
+}
+
+func spread(votes []Vote, field string) (lo, hi, sum float64, err error) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range votes {
+		f, ok := number(v.Result.Output[field])
+		if !ok {
+			return 0, 0, 0, fmt.Errorf("agent %s returned no numeric %s", v.Agent, field)
+		}
+		lo, hi, sum = min(lo, f), max(hi, f), sum+f
+	}
+	return lo, hi, sum, nil
+}
+
+func disagreement(field string, lo, hi, maxSpread float64) error {
+	return fmt.Errorf("agents disagree on %s: %g..%g exceeds spread %g", field, lo, hi, maxSpread)
+}
+
+func number(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+// needsReview is a partial result listing every vote, for a human to settle.
+func needsReview(t tasks.Task, votes []Vote, reason error) tasks.Result {
+	list := make([]any, len(votes))
+	for i, v := range votes {
+		list[i] = map[string]any{"agent": v.Agent, "status": v.Result.Status, "output": v.Result.Output}
+	}
+	return tasks.Result{
+		TaskID: t.ID, Status: agents.StatusPartial, Err: reason,
+		Output: map[string]any{ReviewKey: true, VotesKey: list},
+	}
+}
+
+// executeConsensus runs t on lead plus up to p.Agents-1 more distinct agents,
+// concurrently and each on its own copy of the payload, then reconciles the
+// successful results. Failed votes, or fewer agents than p.Agents, turn an
+// agreed result into one that needs review. lead must already be reserved.
+func (o *Orchestrator) executeConsensus(ctx context.Context, lead agents.Agent, t tasks.Task, p ConsensusPolicy) tasks.Result {
+	ct, tracked := o.Registry.(capacityTracker)
+	es, canExclude := o.Registry.(excludingSelector)
+	chosen := []agents.Agent{lead}
+	names := []string{lead.Name()}
+	for len(chosen) < p.Agents {
+		a, ok := o.nextAgent(es, canExclude, ct, tracked, t, names)
+		if !ok {
+			break
+		}
+		a, err := o.fitDeadline(ctx, a, t, names...)
+		if err != nil {
+			break
+		}
+		o.emitTask(EventSelected, t, a.Name(), "")
+		chosen, names = append(chosen, a), append(names, a.Name())
+	}
+	if tracked {
+		for _, a := range chosen[1:] {
+			defer ct.ReleaseFor(a.Name(), t.Type)
+		}
+	}
+
+	all := make([]Vote, len(chosen))
+	var wg sync.WaitGroup
+	for i, a := range chosen {
+		vt := t
+		vt.Payload = deepCopy(t.Payload)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			all[i] = Vote{Agent: a.Name(), Result: o.executeWithRetry(ctx, a, vt)}
+		}()
+	}
+	wg.Wait()
+
+	var ok []Vote
+	var errs []error
+	for _, v := range all {
+		if v.Result.Status == agents.StatusOK {
+			ok = append(ok, v)
+		} else {
+			errs = append(errs, fmt.Errorf("agent %s: %w", v.Agent, v.Result.Err))
+		}
+	}
+	if len(ok) == 0 {
+		return failed(t.ID, errors.Join(errs...))
+	}
+	rec := p.Reconcile
+	if rec == nil {
+		rec = FlagDisagreement(ScoreKey, 0)
+	}
+	res := rec.Reconcile(t, ok)
+	if res.Status == agents.StatusOK && len(ok) < p.Agents {
+		reason := fmt.Errorf("only %d of %d agents agreed", len(ok), p.Agents)
+		return needsReview(t, all, errors.Join(append([]error{reason}, errs...)...))
+	}
+	return res
+}
@@ -0,0 +1,82 @@
+package orchestrator
+
+// Per-run cost budgets: stop dispatching once a run's agent spend is used up
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+var ErrBudgetExceeded = errors.New("run cost budget exceeded")
+
+type budgetKey struct{}
+
+// WithBudget sets the cost budget of runs started with ctx, overriding
+// Orchestrator.Budget. Zero means unlimited.
+func WithBudget(ctx context.Context, budget float64) context.Context {
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+type runCost struct {
+	limit float64 // zero means unlimited
+	spent float64
+}
+
+type costTable struct {
+	mu   sync.Mutex
+	runs map[string]*runCost
+}
+
+// startCost opens cost accounting for a run.
+func (o *Orchestrator) startCost(ctx context.Context, runID string) {
+	limit := o.Budget
+	if b, ok := ctx.Value(budgetKey{}).(float64); ok {
+		limit = b
+	}
+	o.costs.mu.Lock()
+	defer o.costs.mu.Unlock()
+	if o.costs.runs == nil {
+		o.costs.runs = make(map[string]*runCost)
+	}
+	o.costs.runs[runID] = &runCost{limit: limit}
+}
+
+// endCost closes a run's accounting and returns what it spent.
+func (o *Orchestrator) endCost(runID string) float64 {
+	o.costs.mu.Lock()
+	defer o.costs.mu.Unlock()
+	rc := o.costs.runs[runID]
+	delete(o.costs.runs, runID)
+	if rc == nil {
+		return 0
+	}
+	return rc.spent
+}
+
+// charge books a's estimated cost for t against t's run, or refuses with
+// ErrBudgetExceeded if that would take the run over its budget. Tasks outside
+// a run, and agents without a cost estimate, are never refused.
+func (o *Orchestrator) charge(a agents.Agent, t tasks.Task) error {
+	ce, ok := agents.As[agents.CostEstimator](a)
+	if !ok {
+		return nil
+	}
+	runID := o.runOf(t.ID)
+	o.costs.mu.Lock()
+	defer o.costs.mu.Unlock()
+	rc := o.costs.runs[runID]
+	if rc == nil {
+		return nil
+	}
+	cost := ce.EstimateCost(agents.Task{ID: t.ID, Type: t.Type, Payload: t.Payload})
+	if rc.limit > 0 && rc.spent+cost > rc.limit {
+		return fmt.Errorf("%w: %s on %s costs %g, %g of %g left", ErrBudgetExceeded, t.Type, a.Name(), cost, rc.limit-rc.spent, rc.limit)
+	}
+	rc.spent += cost
+	return nil
+}
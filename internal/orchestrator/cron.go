@@ -0,0 +1,136 @@
+package orchestrator
+
+// Cron expressions: minute hour day-of-month month day-of-week
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrBadCron = errors.New("invalid cron expression")
+
+// CronSpec is a parsed five-field cron expression. Fields accept *, single
+// values, ranges (1-5), lists (1,3,5) and steps (*/15, 0-30/10). Days of the
+// week run 0-6 from Sunday (7 is Sunday too). When both day fields are
+// restricted a time matches if either does, as in classic cron. The macros
+// @hourly, @daily, @weekly, @monthly and @yearly are accepted as well.
+type CronSpec struct {
+	minute, hour, dom, month, dow uint64 // bit sets
+	domAny, dowAny                bool
+}
+
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// ParseCron parses expr.
+func ParseCron(expr string) (CronSpec, error) {
+	if m, ok := cronMacros[strings.TrimSpace(expr)]; ok {
+		expr = m
+	}
+	f := strings.Fields(expr)
+	if len(f) != 5 {
+		return CronSpec{}, fmt.Errorf("%w: %q has %d fields, want 5", ErrBadCron, expr, len(f))
+	}
+	var s CronSpec
+	var err error
+	if s.minute, err = cronField(f[0], 0, 59); err != nil {
+		return CronSpec{}, err
+	}
+	if s.hour, err = cronField(f[1], 0, 23); err != nil {
+		return CronSpec{}, err
+	}
+	if s.dom, err = cronField(f[2], 1, 31); err != nil {
+		return CronSpec{}, err
+	}
+	if s.month, err = cronField(f[3], 1, 12); err != nil {
+		return CronSpec{}, err
+	}
+	if s.dow, err = cronField(f[4], 0, 7); err != nil {
+		return CronSpec{}, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is Sunday
+	}
+	s.domAny, s.dowAny = f[2] == "*", f[4] == "*"
+	return s, nil
+}
+
+func cronField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%w: bad step in %q", ErrBadCron, part)
+			}
+			rng, step = part[:i], n
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("%w: bad value in %q", ErrBadCron, part)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("%w: bad range in %q", ErrBadCron, part)
+				}
+			} else if step > 1 {
+				to = hi // "5/15" means from 5 on
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return 0, fmt.Errorf("%w: %q outside %d-%d", ErrBadCron, part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (s CronSpec) dayMatches(t time.Time) bool {
+	dom, dow := s.dom&(1<<t.Day()) != 0, s.dow&(1<<t.Weekday()) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next returns the first matching minute strictly after t, in t's location,
+// or the zero time if none comes within five years (e.g. "0 0 30 2 *").
+func (s CronSpec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
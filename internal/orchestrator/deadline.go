@@ -0,0 +1,102 @@
+package orchestrator
+
+// Deadline negotiation: skip agents that say they cannot finish in time
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+var ErrInsufficientTime = errors.New("insufficient time before deadline")
+
+// excludingSelector is implemented by registries that can select around
+// named agents, like *agents.Registry.
+type excludingSelector interface {
+	SelectExcluding(taskType string, exclude ...string) (agents.Agent, bool)
+}
+
+// budget returns the time an execution starting now may take: the smaller of
+// TaskTimeout and what is left before ctx's deadline. ok is false when
+// neither bounds it.
+func (o *Orchestrator) budget(ctx context.Context) (time.Duration, bool) {
+	b, ok := o.TaskTimeout, o.TaskTimeout > 0
+	if dl, has := ctx.Deadline(); has {
+		if left := time.Until(dl); !ok || left < b {
+			b, ok = left, true
+		}
+	}
+	return b, ok
+}
+
+// fitDeadline returns a if it estimates it can finish t within the budget,
+// or else releases a and looks for another agent that can. a must already be
+// reserved; the returned agent is reserved in its place and never one named
+// in exclude. Agents without an estimate are assumed to fit.
+func (o *Orchestrator) fitDeadline(ctx context.Context, a agents.Agent, t tasks.Task, exclude ...string) (agents.Agent, error) {
+	budget, bounded := o.budget(ctx)
+	if !bounded {
+		return a, nil
+	}
+	at := agents.Task{ID: t.ID, Type: t.Type, Payload: t.Payload}
+	ct, tracked := o.Registry.(capacityTracker)
+	es, canExclude := o.Registry.(excludingSelector)
+	slow := append([]string(nil), exclude...)
+	var worst time.Duration
+	for {
+		est, ok := agents.As[agents.DurationEstimator](a)
+		if !ok {
+			return a, nil
+		}
+		need := est.EstimateDuration(at)
+		if need <= budget {
+			return a, nil
+		}
+		worst = max(worst, need)
+		if tracked {
+			ct.ReleaseFor(a.Name(), t.Type)
+		}
+		slow = append(slow, a.Name())
+		o.emitTask(EventSelected, t, a.Name(), fmt.Sprintf("declined: needs %s, %s left", need, budget))
+
+		if a, ok = o.nextAgent(es, canExclude, ct, tracked, t, slow); !ok {
+			return nil, fmt.Errorf("%w: %s needs at least %s, %s left", ErrInsufficientTime, t.Type, worst, budget)
+		}
+	}
+}
+
+// nextAgent selects and reserves an agent not named in exclude that accepts
+// t's payload (see agents.PayloadMatcher). Agents rejecting the payload are
+// skipped over without using up an attempt.
+func (o *Orchestrator) nextAgent(es excludingSelector, canExclude bool, ct capacityTracker, tracked bool, t tasks.Task, exclude []string) (agents.Agent, bool) {
+	if !canExclude {
+		return nil, false
+	}
+	exclude = exclude[:len(exclude):len(exclude)] // never append into the caller's slice
+	for attempt := 0; attempt < 3; {
+		a, ok := es.SelectExcluding(t.Type, exclude...)
+		if !ok {
+			return nil, false
+		}
+		if !acceptsPayload(a, t) {
+			exclude = append(exclude, a.Name())
+			continue
+		}
+		if !tracked || ct.AcquireFor(a.Name(), t.Type) {
+			return a, true
+		}
+		attempt++
+	}
+	return nil, false
+}
+
+// acceptsPayload reports whether a accepts t's payload, which agents that do
+// not implement agents.PayloadMatcher always do.
+func acceptsPayload(a agents.Agent, t tasks.Task) bool {
+	m, ok := agents.As[agents.PayloadMatcher](a)
+	return !ok || m.CanHandlePayload(t.Type, t.Payload)
+}
@@ -0,0 +1,98 @@
+package orchestrator
+
+// Idempotent submission on top of the queue's dedup window
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// ErrResultUnavailable is returned by EnqueueOrGetResult for a duplicate whose
+// original finished without its result being kept.
+var ErrResultUnavailable = errors.New("original task finished and its result was not kept")
+
+// taskHolder is implemented by queues that can tell whether they still hold a
+// task, like *tasks.MemoryQueue.
+type taskHolder interface {
+	Holds(taskID string) bool
+}
+
+// EnqueueOrGetResult enqueues t unless a task with the same DedupKey is still
+// inside the queue's dedup window. For a fresh task it returns immediately
+// with reused=false and only TaskID set, so callers can poll for it. For a
+// duplicate it returns the original's result with reused=true: straight
+// from Results if the original was already acked, otherwise by waiting for
+// the original to complete (coalescing), bounded by ctx. Someone must be
+// executing tasks (Run or Serve) for the wait to end. A duplicate of an
+// original the queue no longer holds and whose result is not in Results (no
+// store, a failed Put, or retention already removed it) fails with
+// ErrResultUnavailable instead of waiting for a completion that already
+// happened; queues that cannot tell are waited on.
+func (o *Orchestrator) EnqueueOrGetResult(ctx context.Context, t tasks.Task) (res tasks.Result, reused bool, err error) {
+	o.assignID(&t)
+	err = o.enqueue(ctx, &t)
+	var dup *tasks.DuplicateError
+	if err == nil {
+		return tasks.Result{TaskID: t.ID}, false, nil
+	}
+	if !errors.As(err, &dup) {
+		return tasks.Result{}, false, err
+	}
+
+	// Subscribe before looking in the store so a completion in between is not missed.
+	ch := o.subscribe(dup.OriginalID)
+	defer o.unsubscribe(dup.OriginalID, ch)
+
+	if res, ok, err := o.storedResult(ctx, dup.OriginalID); err != nil || ok {
+		if ok {
+			o.recordReuse(t)
+		}
+		return res, ok, err
+	}
+	if h, ok := o.Queue.(taskHolder); ok && !h.Holds(dup.OriginalID) {
+		select {
+		case res := <-ch: // it completed after all
+			o.recordReuse(t)
+			return res, true, nil
+		default:
+		}
+		// Acked, but its result may have been stored only since we looked.
+		if res, ok, err := o.storedResult(ctx, dup.OriginalID); err != nil || ok {
+			if ok {
+				o.recordReuse(t)
+			}
+			return res, ok, err
+		}
+		return tasks.Result{}, false, fmt.Errorf("%w: %s", ErrResultUnavailable, dup.OriginalID)
+	}
+	select {
+	case res := <-ch:
+		o.recordReuse(t)
+		return res, true, nil
+	case <-ctx.Done():
+		return tasks.Result{}, false, ctx.Err()
+	}
+}
+
+// storedResult looks taskID up in Results, if there is a store.
+func (o *Orchestrator) storedResult(ctx context.Context, taskID string) (tasks.Result, bool, error) {
+	if o.Results == nil {
+		return tasks.Result{}, false, nil
+	}
+	return o.Results.Get(ctx, taskID)
+}
+
+// reuseRecorder is implemented by queues that count dedup outcomes, like
+// *tasks.MemoryQueue.
+type reuseRecorder interface {
+	RecordDedupReuse(taskType string)
+}
+
+func (o *Orchestrator) recordReuse(t tasks.Task) {
+	if rr, ok := o.Queue.(reuseRecorder); ok {
+		rr.RecordDedupReuse(t.Type)
+	}
+}
@@ -0,0 +1,143 @@
+package orchestrator
+
+// Delta merging and checkpointing for agents.DeltaAgent executions
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// CheckpointStore persists the running output of delta executions by task,
+// apart from the final results: a checkpoint is work in progress, never a
+// result to hand out. The orchestrator deletes a task's checkpoint once the
+// task is acked.
+type CheckpointStore interface {
+	Save(ctx context.Context, taskID string, output map[string]any) error
+	Load(ctx context.Context, taskID string) (map[string]any, bool, error)
+	Delete(ctx context.Context, taskID string) error
+}
+
+// MemoryCheckpointStore is a threadsafe in-memory CheckpointStore. It lets a
+// retried or rerouted execution resume, but does not survive a restart.
+type MemoryCheckpointStore struct {
+	mu sync.Mutex
+	m  map[string]map[string]any
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{m: make(map[string]map[string]any)}
+}
+
+func (s *MemoryCheckpointStore) Save(ctx context.Context, taskID string, output map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[taskID] = maps.Clone(output)
+	return nil
+}
+
+func (s *MemoryCheckpointStore) Load(ctx context.Context, taskID string) (map[string]any, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out, ok := s.m[taskID]
+	return maps.Clone(out), ok, nil
+}
+
+func (s *MemoryCheckpointStore) Delete(ctx context.Context, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, taskID)
+	return nil
+}
+
+// dropCheckpoint deletes an acked task's checkpoint. A failed delete only
+// leaves garbage behind: the task is never redelivered.
+func (o *Orchestrator) dropCheckpoint(ctx context.Context, taskID string) {
+	if o.Checkpoints != nil {
+		o.Checkpoints.Delete(ctx, taskID)
+	}
+}
+
+// deltaRun merges one execution's deltas into its running output and
+// checkpoints it at most every CheckpointInterval.
+type deltaRun struct {
+	o     *Orchestrator
+	t     tasks.Task
+	chunk func(agents.ResultChunk)
+
+	mu      sync.Mutex
+	output  map[string]any
+	dirty   bool // output changed since the last checkpoint
+	savedAt time.Time
+}
+
+// deltaAgent adapts a to a plain Agent for executeOn: it resumes from t's
+// checkpoint, if any, merges the deltas with DeltaMerge, streams each as an
+// EventChunk and checkpoints the running output to Checkpoints. A failed
+// execution checkpoints what it got to before returning.
+func (o *Orchestrator) deltaAgent(ctx context.Context, a agents.DeltaAgent, t tasks.Task) agents.Agent {
+	d := &deltaRun{o: o, t: t, chunk: o.chunkSender(t, a.Name()), savedAt: time.Now()}
+	if o.Checkpoints != nil {
+		if out, ok, err := o.Checkpoints.Load(ctx, t.ID); err == nil && ok {
+			d.output = out
+		}
+	}
+	return deltaExec{a, d}
+}
+
+type deltaExec struct {
+	agents.DeltaAgent
+	d *deltaRun
+}
+
+func (e deltaExec) Execute(ctx context.Context, t agents.Task) (agents.Result, error) {
+	d := e.d
+	d.mu.Lock()
+	if len(d.output) > 0 {
+		payload := maps.Clone(t.Payload)
+		if payload == nil {
+			payload = make(map[string]any, 1)
+		}
+		payload[agents.CheckpointKey] = maps.Clone(d.output)
+		t.Payload = payload
+	}
+	d.mu.Unlock()
+
+	r, err := e.ExecuteDeltas(ctx, t, func(delta map[string]any) { d.apply(ctx, delta) })
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r.Output = d.o.DeltaMerge.MergeResults(tasks.Result{Output: d.output}, tasks.Result{Output: r.Output}).Output
+	if err != nil || r.Status == agents.StatusFailed {
+		d.output, d.dirty = r.Output, true
+		d.save(context.WithoutCancel(ctx))
+	}
+	return r, err
+}
+
+// apply merges delta into the running output and checkpoints it when due.
+func (d *deltaRun) apply(ctx context.Context, delta map[string]any) {
+	d.chunk(agents.ResultChunk{Output: delta})
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.output = d.o.DeltaMerge.MergeResults(tasks.Result{Output: d.output}, tasks.Result{Output: delta}).Output
+	d.dirty = true
+	if time.Since(d.savedAt) >= d.o.CheckpointInterval {
+		d.save(ctx)
+	}
+}
+
+// save checkpoints the running output if it changed. A failed save is
+// retried with the next delta. Caller must hold d.mu.
+func (d *deltaRun) save(ctx context.Context) {
+	if d.o.Checkpoints == nil || !d.dirty {
+		return
+	}
+	if d.o.Checkpoints.Save(ctx, d.t.ID, maps.Clone(d.output)) == nil {
+		d.dirty, d.savedAt = false, time.Now()
+	}
+}
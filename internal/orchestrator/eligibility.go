@@ -0,0 +1,61 @@
+package orchestrator
+
+// Eligibility gating
+// Gates are consulted before planning, so runs for learners who do not
+// qualify (e.g. missing prerequisites) never reach an agent.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ngx-workshop/mcp-server/internal/criteria"
+)
+
+var ErrIneligible = errors.New("criteria not eligible for a run")
+
+// EligibilityGate decides whether a run for c may go ahead. An ineligible c
+// comes with a human-readable reason; err is for gates that could not decide.
+type EligibilityGate interface {
+	Eligible(ctx context.Context, c criteria.Criteria) (ok bool, reason string, err error)
+}
+
+// EligibilityFunc adapts a function to EligibilityGate.
+type EligibilityFunc func(ctx context.Context, c criteria.Criteria) (bool, string, error)
+
+func (f EligibilityFunc) Eligible(ctx context.Context, c criteria.Criteria) (bool, string, error) {
+	return f(ctx, c)
+}
+
+// AllGates passes only if every gate does. Gates are consulted in order and
+// the first refusal or error ends the check, so put cheap gates first.
+func AllGates(gates ...EligibilityGate) EligibilityGate {
+	return EligibilityFunc(func(ctx context.Context, c criteria.Criteria) (bool, string, error) {
+		for _, g := range gates {
+			if ok, reason, err := g.Eligible(ctx, c); err != nil || !ok {
+				return false, reason, err
+			}
+		}
+		return true, "", nil
+	})
+}
+
+// checkEligible consults o.Eligibility, recording a refusal in rep. It
+// returns ErrIneligible, wrapped with the reason, for an ineligible c.
+func (o *Orchestrator) checkEligible(ctx context.Context, c criteria.Criteria, rep *Report) error {
+	if o.Eligibility == nil {
+		return nil
+	}
+	ok, reason, err := o.Eligibility.Eligible(ctx, c)
+	if err != nil {
+		return fmt.Errorf("eligibility check: %w", err)
+	}
+	if ok {
+		return nil
+	}
+	if reason == "" {
+		reason = "refused by eligibility gate"
+	}
+	rep.Ineligible = reason
+	return fmt.Errorf("%w: %s", ErrIneligible, reason)
+}
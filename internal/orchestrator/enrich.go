@@ -0,0 +1,49 @@
+package orchestrator
+
+// Payload enrichment: per-type default and computed fields before dispatch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// PayloadEnricher returns fields to add to a task's payload before it is
+// dispatched, e.g. the course config or a rubric reference loaded from an
+// external store. It sees the payload as enriched so far. Returning an error
+// fails the task with the error as its reason.
+type PayloadEnricher func(ctx context.Context, t tasks.Task) (map[string]any, error)
+
+// DefaultFields returns an enricher adding fixed fields. Every task gets its
+// own copy, so agents cannot change the defaults of later tasks.
+func DefaultFields(fields map[string]any) PayloadEnricher {
+	return func(ctx context.Context, t tasks.Task) (map[string]any, error) {
+		return deepCopy(fields), nil
+	}
+}
+
+// enrich applies the enrichers registered for t.Type in order. Fields already
+// in the payload, whether set by the task or an earlier enricher, are never
+// overwritten. The task's payload map itself is not modified.
+func (o *Orchestrator) enrich(ctx context.Context, t tasks.Task) (tasks.Task, error) {
+	list := o.Enrichers[t.Type]
+	if len(list) == 0 {
+		return t, nil
+	}
+	p := cloneMap(t.Payload)
+	for i, e := range list {
+		t.Payload = p
+		fields, err := e(ctx, t)
+		if err != nil {
+			return t, fmt.Errorf("enrich %s payload (enricher %d): %w", t.Type, i, err)
+		}
+		for k, v := range fields {
+			if _, set := p[k]; !set {
+				p[k] = v
+			}
+		}
+	}
+	t.Payload = p
+	return t, nil
+}
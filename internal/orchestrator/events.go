@@ -0,0 +1,285 @@
+package orchestrator
+
+// Replayable event log: an ordered trace of every orchestration step
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+type EventKind string
+
+const (
+	EventPlanned     EventKind = "planned"     // Count = number of planned tasks
+	EventEnqueued    EventKind = "enqueued"    // task accepted by the queue
+	EventSelected    EventKind = "selected"    // Agent chosen; Error if none was or why Agent declined
+	EventStarted     EventKind = "started"     // agent execution began
+	EventFinished    EventKind = "finished"    // Status/Error hold the outcome
+	EventChunk       EventKind = "chunk"       // Chunk holds a streaming agent's partial output; Bus only
+	EventRunDone     EventKind = "run_done"    // a submitted run ended; Status/Error hold the outcome
+	EventRequeued    EventKind = "requeued"    // failed task re-enqueued as TaskID; From is the original
+	EventCoalesced   EventKind = "coalesced"   // TaskID got the result of From's identical execution
+	EventCompensated EventKind = "compensated" // a failed run's compensation for TaskID ran; Error if it failed
+)
+
+// Event is one step of a run. Seq orders events emitted by one orchestrator,
+// even when timestamps tie.
+type Event struct {
+	Seq      uint64    `json:"seq"`
+	At       time.Time `json:"at"`
+	Kind     EventKind `json:"kind"`
+	RunID    string    `json:"runId,omitempty"`
+	TaskID   string    `json:"taskId,omitempty"`
+	TaskType string    `json:"taskType,omitempty"`
+	Agent    string    `json:"agent,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Count    int       `json:"count,omitempty"`
+	From     string    `json:"from,omitempty"`
+	Reason   string    `json:"reason,omitempty"` // EventFinished: why the task was cut short
+
+	Chunk *agents.ResultChunk `json:"chunk,omitempty"` // EventChunk only
+
+	// Payload snapshots, see Orchestrator.TracePayloads.
+	PayloadBefore string `json:"payloadBefore,omitempty"`
+	PayloadAfter  string `json:"payloadAfter,omitempty"`
+}
+
+// EventLog stores events. Record is called from worker goroutines and must be
+// safe for concurrent use; it should not block for long.
+type EventLog interface {
+	Record(e Event)
+}
+
+var eventSeq atomic.Uint64
+
+func (o *Orchestrator) emit(e Event) {
+	if o.Events == nil && o.Bus == nil {
+		return
+	}
+	e.Seq = eventSeq.Add(1)
+	e.At = time.Now()
+	if o.Events != nil {
+		o.Events.Record(e)
+	}
+	if o.Bus != nil {
+		o.Bus.Publish(string(e.Kind), e)
+	}
+}
+
+func (o *Orchestrator) emitTask(kind EventKind, t tasks.Task, agent, errMsg string) {
+	if o.Events == nil && o.Bus == nil {
+		return
+	}
+	o.emit(Event{Kind: kind, RunID: o.runOf(t.ID), TaskID: t.ID, TaskType: t.Type, Agent: agent, Error: errMsg})
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// MemoryEventLog keeps the most recent Capacity events in a ring buffer.
+type MemoryEventLog struct {
+	mu     sync.Mutex
+	buf    []Event
+	next   int
+	filled bool
+}
+
+func NewMemoryEventLog(capacity int) *MemoryEventLog {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &MemoryEventLog{buf: make([]Event, capacity)}
+}
+
+func (l *MemoryEventLog) Record(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf[l.next] = e
+	l.next = (l.next + 1) % len(l.buf)
+	if l.next == 0 {
+		l.filled = true
+	}
+}
+
+// Events returns the retained events, oldest first.
+func (l *MemoryEventLog) Events() []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.filled {
+		return append([]Event(nil), l.buf[:l.next]...)
+	}
+	return append(append([]Event(nil), l.buf[l.next:]...), l.buf[:l.next]...)
+}
+
+// FileEventLog appends events as JSON lines. When the file grows past
+// MaxBytes it is rotated to Path+".1" (replacing any older rotation), which
+// bounds disk use to about twice MaxBytes. Write errors are dropped: the
+// event log must never fail a run.
+type FileEventLog struct {
+	Path     string
+	MaxBytes int64 // defaults to 64 MiB
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func (l *FileEventLog) Record(e Event) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil && l.open() != nil {
+		return
+	}
+	max := l.MaxBytes
+	if max <= 0 {
+		max = 64 << 20
+	}
+	if l.size+int64(len(b)) > max && l.size > 0 {
+		l.f.Close()
+		l.f = nil
+		os.Rename(l.Path, l.Path+".1")
+		if l.open() != nil {
+			return
+		}
+	}
+	n, _ := l.f.Write(b)
+	l.size += int64(n)
+}
+
+func (l *FileEventLog) open() error {
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size = f, fi.Size()
+	return nil
+}
+
+// Close releases the file.
+func (l *FileEventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// ReadEvents parses a JSON-lines event stream such as a FileEventLog file.
+// A torn final line is ignored.
+func ReadEvents(r io.Reader) ([]Event, error) {
+	var out []Event
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			return out, err
+		}
+		out = append(out, e)
+	}
+}
+
+// TaskTimeline is the reconstructed history of one task.
+type TaskTimeline struct {
+	TaskID   string
+	TaskType string
+	Events   []Event // in order
+	Agents   []string
+	Status   string // outcome of the last finished attempt
+	Duration time.Duration
+}
+
+// RunTimeline is the reconstructed history of one run.
+type RunTimeline struct {
+	RunID   string
+	Start   time.Time
+	End     time.Time
+	Planned int
+	Tasks   []TaskTimeline // in order of first appearance
+}
+
+// Replay rebuilds per-run timelines from an event log, e.g. one loaded with
+// ReadEvents. Events are ordered by Seq before replaying; events without a
+// run ID are grouped under the empty run ID.
+func Replay(events []Event) []RunTimeline {
+	sorted := append([]Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Seq < sorted[j].Seq })
+
+	var runs []*RunTimeline
+	byRun := make(map[string]*RunTimeline)
+	taskIdx := make(map[string]map[string]int) // run -> task -> index in Tasks
+	for _, e := range sorted {
+		rt, ok := byRun[e.RunID]
+		if !ok {
+			rt = &RunTimeline{RunID: e.RunID, Start: e.At}
+			byRun[e.RunID] = rt
+			taskIdx[e.RunID] = make(map[string]int)
+			runs = append(runs, rt)
+		}
+		rt.End = e.At
+		if e.Kind == EventPlanned {
+			rt.Planned = e.Count
+		}
+		if e.TaskID == "" {
+			continue
+		}
+		i, ok := taskIdx[e.RunID][e.TaskID]
+		if !ok {
+			i = len(rt.Tasks)
+			taskIdx[e.RunID][e.TaskID] = i
+			rt.Tasks = append(rt.Tasks, TaskTimeline{TaskID: e.TaskID, TaskType: e.TaskType})
+		}
+		tt := &rt.Tasks[i]
+		tt.Events = append(tt.Events, e)
+		switch e.Kind {
+		case EventSelected:
+			if e.Agent != "" && e.Error == "" {
+				tt.Agents = append(tt.Agents, e.Agent)
+			}
+		case EventFinished, EventCoalesced:
+			tt.Status = e.Status
+			tt.Duration = e.At.Sub(tt.Events[0].At)
+		}
+	}
+
+	out := make([]RunTimeline, len(runs))
+	for i, rt := range runs {
+		out[i] = *rt
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package orchestrator
+
+// Task groups: wait for a declared number of tasks to complete (a barrier)
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+var (
+	ErrUnknownGroup = errors.New("unknown task group")
+	ErrGroupSize    = errors.New("task group size missing or inconsistent")
+)
+
+type taskGroup struct {
+	size    int
+	order   []string // task IDs in enqueue order
+	results map[string]tasks.Result
+	done    chan struct{} // closed once every member has a result
+}
+
+type groupTable struct {
+	mu     sync.Mutex
+	groups map[string]*taskGroup
+	member map[string]string // task ID -> group ID, until it completes
+}
+
+// joinGroup records t as a member of its group. The first task of a group
+// declares its size; later ones must agree or leave GroupSize zero.
+func (o *Orchestrator) joinGroup(t tasks.Task) error {
+	if t.GroupID == "" {
+		return nil
+	}
+	g := &o.groups
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.groups == nil {
+		g.groups = make(map[string]*taskGroup)
+		g.member = make(map[string]string)
+	}
+	grp, ok := g.groups[t.GroupID]
+	switch {
+	case !ok && t.GroupSize <= 0:
+		return fmt.Errorf("%w: first task %s of group %s must declare GroupSize", ErrGroupSize, t.ID, t.GroupID)
+	case !ok:
+		grp = &taskGroup{size: t.GroupSize, results: make(map[string]tasks.Result), done: make(chan struct{})}
+		g.groups[t.GroupID] = grp
+	case t.GroupSize != 0 && t.GroupSize != grp.size:
+		return fmt.Errorf("%w: task %s declares %d, group %s has %d", ErrGroupSize, t.ID, t.GroupSize, t.GroupID, grp.size)
+	case len(grp.order) >= grp.size:
+		return fmt.Errorf("%w: group %s already has %d tasks", ErrGroupSize, t.GroupID, grp.size)
+	}
+	grp.order = append(grp.order, t.ID)
+	g.member[t.ID] = t.GroupID
+	return nil
+}
+
+// leaveGroup undoes joinGroup for a task the queue rejected.
+func (o *Orchestrator) leaveGroup(t tasks.Task) {
+	if t.GroupID == "" {
+		return
+	}
+	g := &o.groups
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	grp, ok := g.groups[t.GroupID]
+	if !ok {
+		return
+	}
+	delete(g.member, t.ID)
+	for i, id := range grp.order {
+		if id == t.ID {
+			grp.order = append(grp.order[:i], grp.order[i+1:]...)
+			break
+		}
+	}
+	if len(grp.order) == 0 {
+		delete(g.groups, t.GroupID)
+	}
+}
+
+// groupResult files res with its task's group, if any.
+func (o *Orchestrator) groupResult(res tasks.Result) {
+	g := &o.groups
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id, ok := g.member[res.TaskID]
+	if !ok {
+		return
+	}
+	delete(g.member, res.TaskID)
+	grp := g.groups[id]
+	grp.results[res.TaskID] = res
+	if len(grp.results) == grp.size {
+		close(grp.done)
+	}
+}
+
+// WaitGroup blocks until every task of the group has been acked and returns
+// their results in enqueue order. Only tasks enqueued through the
+// orchestrator (Run, Submit, EnqueueOrGetResult) are tracked. Once a
+// WaitGroup call has returned the complete group it is forgotten, and later
+// calls fail with ErrUnknownGroup.
+func (o *Orchestrator) WaitGroup(ctx context.Context, groupID string) ([]tasks.Result, error) {
+	g := &o.groups
+	g.mu.Lock()
+	grp, ok := g.groups[groupID]
+	g.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGroup, groupID)
+	}
+	select {
+	case <-grp.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.groups, groupID)
+	out := make([]tasks.Result, len(grp.order))
+	for i, id := range grp.order {
+		out[i] = grp.results[id]
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package orchestrator
+
+// Request hedging: race a second agent when the first is slow
+
+import (
+	"context"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// HedgePolicy starts a second agent on a task the first has not finished
+// after Delay. Only task types listed in Orchestrator.Idempotent are hedged.
+type HedgePolicy struct {
+	Delay time.Duration
+}
+
+type hedgeResult struct {
+	agent agents.Agent
+	res   tasks.Result
+}
+
+// executeHedged runs t on lead and, if it is still running after p.Delay, on
+// a second reserved agent too. The first result that is not a failure wins
+// (or the last failure, if both fail); the other execution's context is
+// cancelled, as tasks.CancelFailFast, and its result discarded. Both share
+// the task's idempotency key. lead must already be reserved.
+func (o *Orchestrator) executeHedged(ctx context.Context, lead agents.Agent, t tasks.Task, p HedgePolicy) (tasks.Result, agents.Agent) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(Cancelled(tasks.CancelFailFast)) // stops the loser
+	done := make(chan hedgeResult, 2)             // buffered so the loser never blocks
+	start := func(a agents.Agent, t tasks.Task) {
+		go func() { done <- hedgeResult{a, o.executeWithRetry(ctx, a, t)} }()
+	}
+	start(lead, t)
+	running := 1
+
+	timer := time.NewTimer(p.Delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if a, ok := o.hedgeAgent(ctx, lead, t); ok {
+				ht := t
+				ht.Payload = deepCopy(t.Payload)
+				start(a, ht)
+				running++
+			}
+		case r := <-done:
+			// A failure only loses while the other execution can still win.
+			if running--; r.res.Status != agents.StatusFailed || running == 0 {
+				return r.res, r.agent
+			}
+		}
+	}
+}
+
+// hedgeAgent reserves an agent other than lead for t and releases it again
+// once ctx ends.
+func (o *Orchestrator) hedgeAgent(ctx context.Context, lead agents.Agent, t tasks.Task) (agents.Agent, bool) {
+	ct, tracked := o.Registry.(capacityTracker)
+	es, canExclude := o.Registry.(excludingSelector)
+	a, ok := o.nextAgent(es, canExclude, ct, tracked, t, []string{lead.Name()})
+	if !ok {
+		return nil, false
+	}
+	a, err := o.fitDeadline(ctx, a, t, lead.Name())
+	if err != nil {
+		return nil, false
+	}
+	if tracked {
+		context.AfterFunc(ctx, func() { ct.ReleaseFor(a.Name(), t.Type) })
+	}
+	o.emitTask(EventSelected, t, a.Name(), "")
+	return a, true
+}
@@ -0,0 +1,94 @@
+package orchestrator
+
+// Enqueue hooks: invariants and defaults applied to every task before it is
+// accepted, whether it comes from a plan, EnqueueOrGetResult or a requeue.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+var (
+	ErrInvalidPayload = errors.New("task payload does not match its schema")
+	ErrHookChangedID  = errors.New("enqueue hook changed the task ID")
+)
+
+// EnqueueHook inspects or mutates t before it is enqueued. A non-nil error
+// rejects the task. Hooks may change anything but t.ID.
+type EnqueueHook func(ctx context.Context, t *tasks.Task) error
+
+// runEnqueueHooks applies o.EnqueueHooks to t in order, stopping at the
+// first error.
+func (o *Orchestrator) runEnqueueHooks(ctx context.Context, t *tasks.Task) error {
+	id := t.ID
+	for i, hook := range o.EnqueueHooks {
+		if err := hook(ctx, t); err != nil {
+			return fmt.Errorf("enqueue hook %d: %w", i, err)
+		}
+		if t.ID != id {
+			return fmt.Errorf("enqueue hook %d: %w: %q to %q", i, ErrHookChangedID, id, t.ID)
+		}
+	}
+	return nil
+}
+
+// DefaultTraceField is the payload field StampTraceID writes when given "".
+const DefaultTraceField = "traceId"
+
+type traceIDKey struct{}
+
+// WithTraceID returns ctx carrying the trace ID StampTraceID stamps on tasks
+// enqueued with it.
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, id)
+}
+
+// TraceID returns the trace ID carried by ctx.
+func TraceID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(traceIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// StampTraceID sets payload field (DefaultTraceField if empty) to the trace
+// ID of the enqueueing context, or to a fresh UUID without one. Tasks that
+// already carry the field keep it. The payload is copied first, so the
+// caller's map (a plan's, say) is left as it was.
+func StampTraceID(field string) EnqueueHook {
+	if field == "" {
+		field = DefaultTraceField
+	}
+	return func(ctx context.Context, t *tasks.Task) error {
+		if _, ok := t.Payload[field]; ok {
+			return nil
+		}
+		id, ok := TraceID(ctx)
+		if !ok {
+			id = tasks.UUIDv4{}.NewID()
+		}
+		payload := make(map[string]any, len(t.Payload)+1)
+		maps.Copy(payload, t.Payload)
+		payload[field] = id
+		t.Payload = payload
+		return nil
+	}
+}
+
+// ValidatePayload rejects tasks whose payload does not match the schema
+// registered for their type, with ErrInvalidPayload. Types without a schema
+// pass.
+func ValidatePayload(schemas map[string]OutputSchema) EnqueueHook {
+	return func(ctx context.Context, t *tasks.Task) error {
+		s, ok := schemas[t.Type]
+		if !ok {
+			return nil
+		}
+		if err := s.validate(t.Payload, "payload"); err != nil {
+			return fmt.Errorf("%w: %s task %q: %w", ErrInvalidPayload, t.Type, t.ID, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package orchestrator
+
+// Kafka result publisher
+// The module carries no Kafka client; KafkaProducer is the one call this
+// needs, for an adapter over whichever client the deployment uses.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// KafkaProducer writes one record to a topic and returns once the broker
+// acknowledged it.
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// PartitionKey picks the record key, and with it the partition, of a result.
+type PartitionKey int
+
+const (
+	KeyByTaskID PartitionKey = iota
+	KeyByTenant              // the task's tenant; tasks without one fall back to their ID
+)
+
+// KafkaPublisher publishes every result as JSON to Topic. It waits for the
+// broker, so give it to the orchestrator wrapped in an AsyncPublisher.
+type KafkaPublisher struct {
+	Producer KafkaProducer
+	Topic    string
+	Key      PartitionKey
+}
+
+func (k *KafkaPublisher) Publish(ctx context.Context, r PublishedResult) error {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encode result %s: %w", r.TaskID, err)
+	}
+	key := r.TaskID
+	if k.Key == KeyByTenant && r.TenantID != "" {
+		key = r.TenantID
+	}
+	if err := k.Producer.Produce(ctx, k.Topic, []byte(key), value); err != nil {
+		return fmt.Errorf("produce result %s to %s: %w", r.TaskID, k.Topic, err)
+	}
+	return nil
+}
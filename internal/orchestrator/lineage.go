@@ -0,0 +1,210 @@
+package orchestrator
+
+// Run lineage and cancellation
+// Tasks carry the run they descend from (tasks.Task.RunID), inherited through
+// ParentID, so cancelling a run also reaches follow-ups queued or executing
+// outside of it.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+var ErrRunCancelled = errors.New("run was cancelled")
+
+// DefaultCancelledRunTTL is how long a cancelled run ID is remembered when
+// CancelledRunTTL is unset.
+const DefaultCancelledRunTTL = 24 * time.Hour
+
+// taskFinder is implemented by queues that can list pending tasks, like
+// *tasks.MemoryQueue.
+type taskFinder interface {
+	Find(ctx context.Context, match func(tasks.Task) bool) ([]tasks.Task, error)
+}
+
+// RunCancellation is what Cancel did.
+type RunCancellation struct {
+	Active      bool     // the run itself was still going and was stopped
+	Withdrawn   []string // pending descendants removed from the queue
+	Interrupted []string // descendants whose executions were cancelled
+}
+
+// lineageTable holds, per run ID, the cancel functions Cancel needs and,
+// while the run is in progress, the tasks descending from it.
+type lineageTable struct {
+	mu        sync.Mutex
+	runs      map[string]context.CancelCauseFunc            // runs in progress
+	members   map[string][]string                           // run in progress -> task IDs
+	parents   map[string]string                             // task ID -> run in progress
+	execs     map[string]map[string]context.CancelCauseFunc // run -> task ID -> execution
+	cancelled map[string]time.Time                          // cancelled run -> when that lapses
+}
+
+// runCancelCause ends the contexts of a cancelled run's work.
+var runCancelCause = fmt.Errorf("%w: %w", ErrRunCancelled, Cancelled(tasks.CancelOperator))
+
+// Cancel cancels the run runID and, with it, every task descending from it:
+// the run's own tasks (when it is still going, Run returns ErrRunCancelled),
+// follow-ups whose RunID or ParentID chain leads to it, and corrected
+// retries of them (RequeueModified). Pending descendants are withdrawn from
+// the queue, when it can list them, and their waiters get an ErrSkipped
+// result; executing ones see their context cancelled. Descendants enqueued
+// later are rejected with ErrRunCancelled, and those the queue could not
+// list fail without executing when dequeued. All of them are recorded as
+// tasks.CancelOperator. The cancellation lapses after CancelledRunTTL.
+//
+// Any run ID is accepted, as descendants can outlive their run. Once the run
+// is over, though, only descendants carrying its RunID are reached: a
+// ParentID leads to the run only while the run is in progress, which is
+// when enqueued follow-ups inherit the RunID.
+func (o *Orchestrator) Cancel(ctx context.Context, runID string) (RunCancellation, error) {
+	var out RunCancellation
+	ttl := o.CancelledRunTTL
+	if ttl <= 0 {
+		ttl = DefaultCancelledRunTTL
+	}
+	now := time.Now()
+	l := &o.lineage
+	l.mu.Lock()
+	if l.cancelled == nil {
+		l.cancelled = make(map[string]time.Time)
+	}
+	for id, until := range l.cancelled {
+		if !now.Before(until) {
+			delete(l.cancelled, id)
+		}
+	}
+	l.cancelled[runID] = now.Add(ttl)
+	stopRun, active := l.runs[runID]
+	var stops []context.CancelCauseFunc
+	for id, stop := range l.execs[runID] {
+		out.Interrupted = append(out.Interrupted, id)
+		stops = append(stops, stop)
+	}
+	l.mu.Unlock()
+
+	if active {
+		out.Active = true
+		stopRun(runCancelCause)
+	}
+	for _, stop := range stops {
+		stop(runCancelCause)
+	}
+
+	f, ok := o.Queue.(taskFinder)
+	if !ok {
+		return out, nil
+	}
+	// Tasks of the run's current phase are left to the run, which lists
+	// them in its Report.Skipped.
+	pending, err := f.Find(ctx, func(t tasks.Task) bool { return t.RunID == runID && o.runOf(t.ID) != runID })
+	if err != nil {
+		return out, err
+	}
+	wctx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	cancel(runCancelCause)
+	for _, t := range pending {
+		if o.withdraw(wctx, t.ID) {
+			out.Withdrawn = append(out.Withdrawn, t.ID)
+			res := failed(t.ID, ErrSkipped)
+			res.CancelReason = tasks.CancelOperator
+			o.signal(res)
+		}
+	}
+	return out, nil
+}
+
+// inheritRun fills in t's RunID from its parent, when the parent descends
+// from a run in progress, and rejects descendants of cancelled runs.
+func (o *Orchestrator) inheritRun(t *tasks.Task) error {
+	l := &o.lineage
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t.RunID == "" && t.ParentID != "" {
+		t.RunID = l.parents[t.ParentID]
+	}
+	if t.RunID == "" {
+		return nil
+	}
+	if l.isCancelled(t.RunID) {
+		return fmt.Errorf("%w: %s", ErrRunCancelled, t.RunID)
+	}
+	if _, ok := l.runs[t.RunID]; ok && t.ID != "" {
+		if l.parents == nil {
+			l.parents = make(map[string]string)
+			l.members = make(map[string][]string)
+		}
+		l.parents[t.ID] = t.RunID
+		l.members[t.RunID] = append(l.members[t.RunID], t.ID)
+	}
+	return nil
+}
+
+// isCancelled reports whether runID was cancelled and that has not lapsed.
+// Caller must hold l.mu.
+func (l *lineageTable) isCancelled(runID string) bool {
+	until, ok := l.cancelled[runID]
+	return ok && time.Now().Before(until)
+}
+
+// startRun derives the context of run runID, which Cancel can end. Call the
+// returned function when the run is over.
+func (l *lineageTable) startRun(ctx context.Context, runID string) (context.Context, func()) {
+	ctx, stop := context.WithCancelCause(ctx)
+	l.mu.Lock()
+	if l.runs == nil {
+		l.runs = make(map[string]context.CancelCauseFunc)
+	}
+	l.runs[runID] = stop
+	if l.isCancelled(runID) {
+		stop(runCancelCause)
+	}
+	l.mu.Unlock()
+	return ctx, func() {
+		l.mu.Lock()
+		delete(l.runs, runID)
+		for _, id := range l.members[runID] {
+			delete(l.parents, id)
+		}
+		delete(l.members, runID)
+		l.mu.Unlock()
+		stop(nil)
+	}
+}
+
+// track derives the execution context of t, which Cancel can end. It is
+// already done when t's run was cancelled. Call the returned function once
+// the execution is over.
+func (l *lineageTable) track(ctx context.Context, t tasks.Task) (context.Context, func()) {
+	if t.RunID == "" {
+		return ctx, func() {}
+	}
+	ctx, stop := context.WithCancelCause(ctx)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isCancelled(t.RunID) {
+		stop(runCancelCause)
+		return ctx, func() {}
+	}
+	if l.execs == nil {
+		l.execs = make(map[string]map[string]context.CancelCauseFunc)
+	}
+	if l.execs[t.RunID] == nil {
+		l.execs[t.RunID] = make(map[string]context.CancelCauseFunc)
+	}
+	l.execs[t.RunID][t.ID] = stop
+	return ctx, func() {
+		l.mu.Lock()
+		delete(l.execs[t.RunID], t.ID)
+		if len(l.execs[t.RunID]) == 0 {
+			delete(l.execs, t.RunID)
+		}
+		l.mu.Unlock()
+		stop(nil)
+	}
+}
@@ -0,0 +1,123 @@
+package orchestrator
+
+// Load level: a lightweight admission-control signal for callers
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type Load int
+
+const (
+	LoadLow Load = iota
+	LoadMedium
+	LoadHigh
+	LoadCritical
+)
+
+func (l Load) String() string {
+	switch l {
+	case LoadLow:
+		return "low"
+	case LoadMedium:
+		return "medium"
+	case LoadHigh:
+		return "high"
+	default:
+		return "critical"
+	}
+}
+
+// LoadThresholds holds the Medium, High and Critical cut-offs for each signal.
+// The overall level is the highest level any signal reaches.
+type LoadThresholds struct {
+	QueueDepth  [3]int     // pending tasks
+	Utilization [3]float64 // in-flight executions / (Workers + TypeWorkers)
+	FailureRate [3]float64 // failed share of the last failureWindow results
+}
+
+var DefaultLoadThresholds = LoadThresholds{
+	QueueDepth:  [3]int{100, 1000, 10000},
+	Utilization: [3]float64{0.5, 0.8, 1.0},
+	FailureRate: [3]float64{0.05, 0.2, 0.5},
+}
+
+const failureWindow = 128
+
+// depthReporter is implemented by queues that can report their depth, like
+// *tasks.MemoryQueue.
+type depthReporter interface {
+	Len() (pending, inflight int)
+}
+
+// loadState tracks the inputs to LoadLevel.
+type loadState struct {
+	inflight atomic.Int64
+
+	mu      sync.Mutex
+	recent  [failureWindow]bool // true = failed
+	n, next int
+}
+
+func (s *loadState) record(failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recent[s.next] = failed
+	s.next = (s.next + 1) % failureWindow
+	if s.n < failureWindow {
+		s.n++
+	}
+}
+
+func (s *loadState) failureRate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.n == 0 {
+		return 0
+	}
+	failed := 0
+	for i := 0; i < s.n; i++ {
+		if s.recent[i] {
+			failed++
+		}
+	}
+	return float64(failed) / float64(s.n)
+}
+
+// LoadLevel combines queue depth, worker utilization and the recent failure
+// rate into a coarse level callers can use to throttle themselves.
+func (o *Orchestrator) LoadLevel() Load {
+	th := DefaultLoadThresholds
+	if o.LoadThresholds != nil {
+		th = *o.LoadThresholds
+	}
+
+	level := LoadLow
+	if dr, ok := o.Queue.(depthReporter); ok {
+		pending, _ := dr.Len()
+		level = max(level, levelInt(pending, th.QueueDepth))
+	}
+	util := float64(o.load.inflight.Load()) / float64(o.capacity())
+	level = max(level, levelFloat(util, th.Utilization))
+	level = max(level, levelFloat(o.load.failureRate(), th.FailureRate))
+	return level
+}
+
+func levelInt(v int, cut [3]int) Load {
+	for i := 2; i >= 0; i-- {
+		if cut[i] > 0 && v >= cut[i] {
+			return Load(i + 1)
+		}
+	}
+	return LoadLow
+}
+
+func levelFloat(v float64, cut [3]float64) Load {
+	for i := 2; i >= 0; i-- {
+		if cut[i] > 0 && v >= cut[i] {
+			return Load(i + 1)
+		}
+	}
+	return LoadLow
+}
@@ -4,16 +4,205 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/ngx-workshop/mcp-server/internal/agents"
 	"github.com/ngx-workshop/mcp-server/internal/criteria"
 	"github.com/ngx-workshop/mcp-server/internal/tasks"
 )
 
+var (
+	ErrNoAgent          = errors.New("no agent available for task type")
+	ErrRunTimeout       = errors.New("run exceeded its timeout")
+	ErrSkipped          = errors.New("task dropped from the queue before it ran")
+	ErrCriteriaNotFound = errors.New("no stored criteria for learner and course")
+)
+
 type Orchestrator struct {
 	Planner  Planner
 	Queue    tasks.Queue
 	Registry AgentRegistry
+	Results  tasks.ResultStore // optional; every final result is stored here
+	IDs      tasks.IDGenerator // fills empty task and run IDs; defaults to UUIDv7
+	States   *tasks.StateStore // optional; share with the queue (tasks.WithStateStore)
+
+	// Criteria, when set, is where RunFor and SubmitFor load criteria from.
+	Criteria criteria.CriteriaStore
+
+	Workers     int           // concurrent executions during Run; defaults to 1
+	TaskTimeout time.Duration // per-execution deadline; zero means none
+	// WaitForAgent makes workers block (bounded by the run's context) until a
+	// capable agent has spare capacity instead of failing with ErrNoAgent.
+	// Tasks whose type no agent handles at all wait until the run ends.
+	WaitForAgent bool
+	RunTimeout   time.Duration // wall-clock budget for a whole Run; zero means none
+
+	// TypeWorkers gives the listed task types pools of their own, of the
+	// given size, in addition to the Workers shared by all other types.
+	// Their tasks never run on a shared worker, so a backlog of one type
+	// does not delay the others, and stay queued until a pool executor is
+	// free. It needs a queue that can dequeue by type, like
+	// tasks.MemoryQueue; with any other queue every type shares Workers.
+	TypeWorkers map[string]int
+
+	// AntiAffinity sends consecutive tasks of a run to different agents
+	// where it can: a task whose chosen agent also ran the run's previous
+	// task goes to another capable, healthy agent if there is one.
+	AntiAffinity bool
+
+	// PartialRetries is how many times a task that came back partial is
+	// re-dispatched with only its remaining payload (see agents.RemainingKey).
+	// Zero keeps the first partial result as final.
+	PartialRetries int
+	// PartialMerge combines each retry's output with the partial output it
+	// continues, so completed sub-work is kept. Defaults to MergeAppend.
+	PartialMerge tasks.MergeStrategy
+
+	// DeltaMerge folds the deltas of agents.DeltaAgent executions into their
+	// running output; defaults to MergeAppend. With Checkpoints set, the
+	// running output is saved at most every CheckpointInterval (zero: after
+	// every delta) and when the execution fails, and a redelivered task
+	// resumes from it (see agents.CheckpointKey) until it is acked.
+	DeltaMerge         tasks.MergeStrategy
+	Checkpoints        CheckpointStore
+	CheckpointInterval time.Duration
+
+	// Retry re-runs failed executions on the same agent. Agents implementing
+	// agents.Retrier override it; the zero value never retries.
+	Retry agents.RetryPolicy
+
+	// Offload, when set, moves large payload fields to a blob store while
+	// tasks sit in the queue and restores them before execution. A task's
+	// blobs are deleted when it is acked or, if RetainFailed keeps it, once
+	// it is requeued or evicted.
+	Offload *PayloadOffload
+
+	// Enrichers, per task type, add default or computed payload fields
+	// before dispatch, in order, never overwriting fields already set.
+	Enrichers map[string][]PayloadEnricher
+
+	// RequiredOutputs lists, per task type, the Output fields a successful
+	// result must contain. See checkResult for the other invariants.
+	RequiredOutputs map[string][]string
+	// OutputSchemas, per task type, declares the fields and types a
+	// successful result's Output must have.
+	OutputSchemas map[string]OutputSchema
+
+	// ResultLimits bounds every agent output; TypeResultLimits overrides it
+	// per task type. Oversized results fail with ErrResultTooLarge.
+	ResultLimits     ResultLimits
+	TypeResultLimits map[string]ResultLimits
+
+	// Consensus, per task type, runs each task on several distinct agents
+	// and reconciles their results instead of trusting a single one.
+	Consensus map[string]ConsensusPolicy
+
+	// Hedge, per task type, races a second agent against a slow first one.
+	// It only applies to types marked in Idempotent: task types whose
+	// executions are safe to run twice at once, e.g. because agents honor
+	// agents.IdempotencyKey.
+	Hedge      map[string]HedgePolicy
+	Idempotent map[string]bool
+
+	// CoalesceInflight makes a task wait for, and share the result of, an
+	// execution already running for a task with the same type and DedupKey,
+	// so the agent runs once. Tasks without a DedupKey always execute.
+	CoalesceInflight bool
+
+	// EnqueueHooks validate and adjust every task, in order, before it is
+	// enqueued; the first error rejects the task. See StampTraceID and
+	// ValidatePayload for built-in ones.
+	EnqueueHooks []EnqueueHook
+
+	// Publisher, when set, receives every acked result. It runs on the
+	// worker's path: wrap slow ones, like KafkaPublisher, in an
+	// AsyncPublisher, which also retries failures. Errors it returns are
+	// counted in ExecStats.PublishFailures. Nil publishes nothing
+	// (NopPublisher).
+	Publisher ResultPublisher
+
+	// Transformers post-process every result, in order, before ack/store.
+	Transformers []ResultTransformer
+
+	// Events, when set, receives an ordered trace of every orchestration step.
+	Events EventLog
+	// Bus, when set, also receives every event, published under its Kind,
+	// for in-process subscribers, plus the EventChunk events that Events
+	// does not get.
+	Bus *EventBus
+	// TracePayloads, when set, adds redacted, size-bounded snapshots of the
+	// task payload from before and after execution to EventFinished events,
+	// to trace how agents change data flowing through chained tasks.
+	TracePayloads *PayloadTrace
+
+	// Shadows, when set, receives the comparison of every shadow agent's
+	// result (see agents.Registry.RegisterShadow) with the primary's. Without
+	// it shadow agents are not run.
+	Shadows ShadowRecorder
+
+	// LoadThresholds tunes LoadLevel; nil uses DefaultLoadThresholds.
+	LoadThresholds *LoadThresholds
+
+	// TenantLimits caps concurrent executions per Task.TenantID; tenants not
+	// listed get TenantLimit. Zero means unlimited. Tasks over their cap wait
+	// without holding a worker, and fail if their context ends first.
+	TenantLimits map[string]int
+	TenantLimit  int
+
+	// Budget caps the summed agents.CostEstimator estimates of every agent
+	// execution in a run; WithBudget overrides it per run. Executions that
+	// would exceed it fail with ErrBudgetExceeded. Zero means unlimited.
+	Budget float64
+
+	// Eligibility, when set, is consulted before planning; a run it refuses
+	// ends with ErrIneligible and Report.Ineligible, without executing
+	// anything. Combine several gates with AllGates.
+	Eligibility EligibilityGate
+
+	// Alerter, when set, is paged on the conditions Alerts configures; see
+	// CheckAlerts and WatchAlerts.
+	Alerter Alerter
+	Alerts  AlertPolicy
+
+	// RetainFailed is how many failed tasks, most recent first, are kept
+	// for RequeueModified. Zero keeps none.
+	RetainFailed int
+
+	// RetainRuns is how many finished Submit runs, most recent first,
+	// RunStatus still reports; zero means DefaultRetainRuns. Older ones are
+	// forgotten. Runs in progress are always kept.
+	RetainRuns int
+
+	// CancelledRunTTL is how long after Cancel descendants of the run are
+	// still rejected and stopped; zero means DefaultCancelledRunTTL.
+	CancelledRunTTL time.Duration
+
+	// Compensations, per task type, undo a completed task's side effects
+	// when its run fails: ends with an error or with a failed task. They
+	// run best-effort once the run is over, latest phase first and, within
+	// a phase, in reverse plan order (not the order the tasks completed
+	// in), and are listed in Report.Compensations; Run's error stays the
+	// run's own.
+	Compensations map[string]Compensator
+
+	load     loadState
+	counters execCounters
+	idOnce   sync.Once
+	mu       sync.Mutex
+	waiters  map[string][]chan tasks.Result // task ID -> completion signals
+	taskRuns map[string]string              // task ID -> run ID, while the run is active
+	runs     runTable
+	tenants  tenantGate
+	groups   groupTable
+	costs    costTable
+	failed   failedTable
+	flights  flightTable
+	spreads  spreadTable
+	lineage  lineageTable
+	alerts   alertState
 }
 
 type Planner interface {
@@ -23,3 +212,721 @@ type Planner interface {
 type AgentRegistry interface {
 	Select(taskType string) (agents.Agent, bool)
 }
+
+// bestSelector is implemented by registries that can rank agents by
+// suitability, like *agents.Registry.
+type bestSelector interface {
+	SelectBest(t tasks.Task) (agents.Agent, bool)
+}
+
+// taskSelector is implemented by registries that route on payload as well as
+// type, like *agents.Registry.
+type taskSelector interface {
+	SelectTask(t tasks.Task) (agents.Agent, bool)
+}
+
+// changeNotifier is implemented by registries that signal when an agent may
+// have become selectable, like *agents.Registry.
+type changeNotifier interface {
+	Changed() <-chan struct{}
+}
+
+// capacityTracker is implemented by registries that account in-flight
+// executions per agent and task type, like *agents.Registry.
+type capacityTracker interface {
+	AcquireFor(name, taskType string) bool
+	ReleaseFor(name, taskType string)
+}
+
+// waitSelector is implemented by registries that can block until an agent
+// frees up, like *agents.Registry.
+type waitSelector interface {
+	SelectWait(ctx context.Context, taskType string) (agents.Agent, error)
+}
+
+// limitsProvider is implemented by registries that carry per-agent execution
+// limits, like *agents.Registry.
+type limitsProvider interface {
+	Limits(name string) (agents.Limits, bool)
+}
+
+// executionObserver is implemented by registries that track how close
+// executions come to their timeout, like *agents.Registry.
+type executionObserver interface {
+	ObserveExecution(name string, took, timeout time.Duration)
+}
+
+// Report summarizes a Run. Results are in plan order; tasks that never ran
+// are listed in Skipped instead.
+type Report struct {
+	RunID     string
+	Results   []tasks.Result
+	Succeeded int
+	Failed    int
+	Partial   int
+
+	TimedOut bool     // the run (or the caller's context) hit its deadline
+	Skipped  []string // IDs of tasks dropped from the queue before they ran
+
+	Cost       float64  // summed cost estimates of the run's agent executions
+	OverBudget []string // IDs of tasks refused because the budget ran out
+
+	Ineligible string // why the eligibility gate refused the run, see Orchestrator.Eligibility
+
+	// Compensations lists the compensating actions a failed run ran, in
+	// the order they ran; see Orchestrator.Compensations.
+	Compensations []Compensation
+
+	steps []sagaStep
+}
+
+func (r *Report) add(res tasks.Result) {
+	r.Results = append(r.Results, res)
+	switch res.Status {
+	case agents.StatusOK:
+		r.Succeeded++
+	case agents.StatusPartial:
+		r.Partial++
+	default:
+		r.Failed++
+	}
+	if errors.Is(res.Err, ErrBudgetExceeded) {
+		r.OverBudget = append(r.OverBudget, res.TaskID)
+	}
+}
+
+// Run plans tasks for c, enqueues them and executes them with Workers
+// goroutines (and the TypeWorkers pools) until every planned task has a result.
+//
+// With RunTimeout set the whole run, planning included, gets a deadline that
+// composes with any deadline already on ctx (the earlier one wins). When ctx
+// ends early, tasks still waiting in the queue are dropped and listed in
+// Report.Skipped, in-flight executions see their context cancelled, and the
+// report keeps whatever results (including partial ones) came back. The
+// returned error is then ErrRunTimeout for an expired RunTimeout, or ctx's
+// own error otherwise. Results and states of the tasks cut short carry a
+// tasks.CancelReason saying why, see Cancelled.
+//
+// A PhasedPlanner (see Phased) is asked for one phase at a time, each
+// planned from the results of the previous ones; the report covers all of
+// them.
+func (o *Orchestrator) Run(ctx context.Context, c criteria.Criteria) (Report, error) {
+	return o.run(ctx, o.newID(), c)
+}
+
+// RunFor is Run for the criteria stored in Criteria for the learner and
+// course. Missing criteria fail with ErrCriteriaNotFound.
+func (o *Orchestrator) RunFor(ctx context.Context, learnerID, courseID string) (Report, error) {
+	c, err := o.loadCriteria(ctx, learnerID, courseID)
+	if err != nil {
+		return Report{}, err
+	}
+	return o.Run(ctx, c)
+}
+
+func (o *Orchestrator) loadCriteria(ctx context.Context, learnerID, courseID string) (criteria.Criteria, error) {
+	if o.Criteria == nil {
+		return criteria.Criteria{}, fmt.Errorf("%w: no criteria store configured", ErrCriteriaNotFound)
+	}
+	c, ok, err := o.Criteria.Get(ctx, learnerID, courseID)
+	if err != nil {
+		return criteria.Criteria{}, fmt.Errorf("load criteria: %w", err)
+	}
+	if !ok {
+		return criteria.Criteria{}, fmt.Errorf("%w: %s/%s", ErrCriteriaNotFound, learnerID, courseID)
+	}
+	return c, nil
+}
+
+func (o *Orchestrator) run(ctx context.Context, runID string, c criteria.Criteria) (rep Report, err error) {
+	if o.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, o.RunTimeout, ErrRunTimeout)
+		defer cancel()
+	}
+	ctx, finish := o.lineage.startRun(ctx, runID)
+	defer finish()
+	ctx = withCancelReason(ctx, tasks.CancelOperator)
+	o.startCost(ctx, runID)
+	defer o.spreads.forget(runID)
+	rep.RunID = runID
+	defer func() { rep.Cost = o.endCost(runID) }()
+	defer func() {
+		if err != nil || rep.Failed > 0 {
+			o.compensate(ctx, &rep)
+		}
+	}()
+
+	if err := o.checkEligible(ctx, c, &rep); err != nil {
+		o.emit(Event{Kind: EventPlanned, RunID: runID, Error: err.Error()})
+		return rep, err
+	}
+
+	stop, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var idle <-chan error // started with the first enqueued phase
+
+	pp, phased := o.Planner.(PhasedPlanner)
+	var prior []tasks.Result
+	for {
+		var plan []tasks.Task
+		done := true
+		if phased {
+			plan, done, err = pp.NextPhase(ctx, c, prior)
+		} else {
+			plan, err = o.Planner.Plan(ctx, c)
+		}
+		if err != nil {
+			o.emit(Event{Kind: EventPlanned, RunID: runID, Error: err.Error()})
+			return rep, err
+		}
+		o.emit(Event{Kind: EventPlanned, RunID: runID, Count: len(plan)})
+
+		results, err := o.runPhase(ctx, stop, runID, plan, &idle, &rep)
+		if err != nil {
+			return rep, err
+		}
+		if done || len(plan) == 0 {
+			return rep, nil
+		}
+		prior = append(prior, results...)
+	}
+}
+
+// runPhase enqueues plan and collects its results into rep, starting the
+// workers on first use. It returns the phase's results for the next phase to
+// build on, or the run's error when the run must end (see Run).
+func (o *Orchestrator) runPhase(ctx, stop context.Context, runID string, plan []tasks.Task, idle *<-chan error, rep *Report) ([]tasks.Result, error) {
+	for i := range plan {
+		o.assignID(&plan[i])
+		plan[i].RunID = runID
+	}
+	waits := o.await(runID, plan)
+	defer o.forget(plan, waits)
+	n, enqErr := len(plan), error(nil)
+	for i := range plan {
+		if err := o.enqueue(ctx, &plan[i]); err != nil {
+			n, enqErr = i, err
+			break
+		}
+		o.emit(Event{Kind: EventEnqueued, RunID: runID, TaskID: plan[i].ID, TaskType: plan[i].Type})
+	}
+	var withdrawn []bool
+	if enqErr != nil {
+		withdrawn = o.abandon(ctx, plan[:n], rep)
+	}
+	if *idle == nil {
+		*idle = o.startWorkers(stop, ctx)
+	}
+
+	results := make([]*tasks.Result, len(plan))
+	var runErr error
+	stopped := false
+collect:
+	for i, ch := range waits[:n] {
+		if withdrawn != nil && withdrawn[i] {
+			continue
+		}
+		select {
+		case res := <-ch:
+			results[i] = &res
+		case <-ctx.Done():
+			runErr = context.Cause(ctx)
+			break collect
+		case err := <-*idle:
+			runErr, stopped = err, true
+			break collect
+		}
+	}
+
+	if runErr != nil && ctx.Err() != nil {
+		rep.TimedOut = errors.Is(runErr, ErrRunTimeout) || errors.Is(runErr, context.DeadlineExceeded)
+		rep.Skipped = append(rep.Skipped, o.dropPending(ctx, plan, results)...)
+		if !stopped {
+			<-*idle
+		}
+		o.drainInflight(waits, results)
+	}
+	rep.recordSteps(plan, results)
+	out := make([]tasks.Result, 0, len(results))
+	for _, res := range results {
+		if res != nil {
+			rep.add(*res)
+			out = append(out, *res)
+		}
+	}
+	if enqErr != nil {
+		return out, enqErr
+	}
+	return out, runErr
+}
+
+// pendingDropper is implemented by queues that can withdraw a task that has
+// not been dequeued yet, like *tasks.MemoryQueue.
+type pendingDropper interface {
+	Drop(taskID string, state tasks.TaskState) bool
+}
+
+// reasonDropper is implemented by queues that also record why a task was
+// withdrawn, like *tasks.MemoryQueue.
+type reasonDropper interface {
+	DropReason(taskID string, reason tasks.CancelReason) bool
+}
+
+// dropPending withdraws the run's unfinished tasks that are still queued and
+// returns their IDs.
+func (o *Orchestrator) dropPending(ctx context.Context, plan []tasks.Task, results []*tasks.Result) []string {
+	var skipped []string
+	for i, t := range plan {
+		if results[i] == nil && o.withdraw(ctx, t.ID) {
+			skipped = append(skipped, t.ID)
+		}
+	}
+	return skipped
+}
+
+// drainInflight picks up results of executions that were still running when
+// the run ended. Call it once every worker has stopped: their contexts were
+// cancelled, so they finish promptly.
+func (o *Orchestrator) drainInflight(waits []chan tasks.Result, results []*tasks.Result) {
+	for i, ch := range waits {
+		if results[i] != nil {
+			continue
+		}
+		select {
+		case res := <-ch:
+			results[i] = &res
+		default:
+		}
+	}
+}
+
+// newID returns a fresh ID from IDs, installing the default on first use.
+func (o *Orchestrator) newID() string {
+	o.idOnce.Do(func() {
+		if o.IDs == nil {
+			o.IDs = &tasks.UUIDv7{}
+		}
+	})
+	return o.IDs.NewID()
+}
+
+func (o *Orchestrator) assignID(t *tasks.Task) {
+	if t.ID == "" {
+		t.ID = o.newID()
+	}
+}
+
+// Serve executes tasks from the queue with Workers goroutines and the
+// TypeWorkers pools until ctx is done, for tasks enqueued outside of Run
+// (e.g. EnqueueOrGetResult). Tasks cut short by the end of ctx are recorded
+// as CancelShutdown unless its cause says otherwise (see Cancelled).
+func (o *Orchestrator) Serve(ctx context.Context) error {
+	ctx = withCancelReason(ctx, tasks.CancelShutdown)
+	err := <-o.startWorkers(ctx, ctx)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// startWorkers launches the dequeue loops and the type pools. The returned
+// channel yields the dequeue error once every worker has stopped.
+func (o *Orchestrator) startWorkers(stop, ctx context.Context) <-chan error {
+	n := o.Workers
+	if n <= 0 {
+		n = 1
+	}
+	var once sync.Once
+	var firstErr error
+	failed := func(err error) { once.Do(func() { firstErr = err }) }
+	pools := o.startPools(stop, ctx, failed)
+	match := pools.sharedMatch()
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				t, err := o.dequeue(stop, match)
+				if err != nil {
+					failed(err)
+					return
+				}
+				if !o.admitTenant(ctx, t) {
+					continue // parked until the tenant has a free slot
+				}
+				o.work(ctx, t, pools, nil)
+			}
+		}()
+	}
+	idle := make(chan error, 1)
+	go func() {
+		wg.Wait()
+		pools.stop()
+		idle <- firstErr
+	}()
+	return idle
+}
+
+// dequeue takes the next task match accepts; a nil match accepts any.
+func (o *Orchestrator) dequeue(ctx context.Context, match func(tasks.Task) bool) (tasks.Task, error) {
+	if match == nil {
+		return o.Queue.Dequeue(ctx)
+	}
+	return o.Queue.(typeDequeuer).DequeueMatching(ctx, match)
+}
+
+// runTask executes t and completes it with the result. A task whose context
+// ended while it waited in a type pool's backlog, or whose run was
+// cancelled, fails without executing.
+func (o *Orchestrator) runTask(ctx context.Context, t tasks.Task) {
+	ctx, release := o.lineage.track(ctx, t)
+	defer release()
+	if ctx.Err() != nil {
+		res := failed(t.ID, context.Cause(ctx))
+		res.CancelReason = reasonFor(ctx)
+		o.complete(context.WithoutCancel(ctx), t, res)
+		return
+	}
+	o.load.inflight.Add(1)
+	began := time.Now()
+	res := o.process(ctx, t)
+	res.CancelReason = cancelReason(ctx, res)
+	o.counters.record(t.Type, res.Status, time.Since(began))
+	o.load.inflight.Add(-1)
+	if res.Status == agents.StatusFailed {
+		o.retainFailed(t)
+	}
+	o.complete(ctx, t, res)
+}
+
+// process executes t and, while the agent keeps returning partial results
+// with resumable work, re-dispatches only the remainder and merges each
+// retry into what was already done. A failed retry never discards an earlier
+// partial result.
+func (o *Orchestrator) process(ctx context.Context, t tasks.Task) tasks.Result {
+	if o.States != nil {
+		if err := o.States.Transition(t.ID, tasks.StateRunning); err != nil {
+			return failed(t.ID, err)
+		}
+	}
+	t, err := o.rehydrate(ctx, t)
+	if err != nil {
+		return failed(t.ID, err)
+	}
+	if t, err = o.enrich(ctx, t); err != nil {
+		return failed(t.ID, err)
+	}
+	res := o.executeCoalesced(ctx, t)
+	for i := 0; i < o.PartialRetries && res.Status == agents.StatusPartial; i++ {
+		rest, ok := agents.Remaining(res.Output)
+		if !ok || ctx.Err() != nil {
+			break
+		}
+		next := t
+		next.Payload = rest
+		retry := o.execute(ctx, next)
+		if retry.Status == agents.StatusFailed {
+			break
+		}
+		res = o.PartialMerge.MergeResults(res, retry)
+		// What is still left is whatever the latest attempt says, never a merge.
+		if rest, left := retry.Output[agents.RemainingKey]; left {
+			res.Output[agents.RemainingKey] = rest
+		} else {
+			delete(res.Output, agents.RemainingKey)
+		}
+	}
+	return res
+}
+
+// execute runs a single attempt of t on a selected agent.
+func (o *Orchestrator) execute(ctx context.Context, t tasks.Task) tasks.Result {
+	a, ok := o.acquireAgent(ctx, t)
+	if !ok {
+		o.emitTask(EventSelected, t, "", ErrNoAgent.Error())
+		o.alertNoAgent(ctx, t.Type)
+		return failed(t.ID, ErrNoAgent)
+	}
+	a, err := o.fitDeadline(ctx, a, t)
+	if err != nil {
+		o.emitTask(EventSelected, t, "", err.Error())
+		return failed(t.ID, err)
+	}
+	if ct, ok := o.Registry.(capacityTracker); ok {
+		defer ct.ReleaseFor(a.Name(), t.Type)
+	}
+	o.emitTask(EventSelected, t, a.Name(), "")
+
+	compare := o.startShadows(ctx, a, t)
+	tracing := o.Events != nil && o.TracePayloads != nil
+	var before string
+	if tracing {
+		before = o.snapshotPayload(ctx, t.ID, t.Payload)
+	}
+	var res tasks.Result
+	if p, ok := o.Consensus[t.Type]; ok && p.Agents > 1 {
+		res = o.executeConsensus(ctx, a, t, p)
+	} else if h, ok := o.Hedge[t.Type]; ok && o.Idempotent[t.Type] {
+		res, a = o.executeHedged(ctx, a, t, h)
+	} else {
+		res = o.executeWithRetry(ctx, a, t)
+	}
+	compare(res)
+	res.CancelReason = cancelReason(ctx, res)
+	e := Event{
+		Kind: EventFinished, RunID: o.runOf(t.ID), TaskID: t.ID, TaskType: t.Type,
+		Agent: a.Name(), Status: res.Status, Error: errString(res.Err),
+	}
+	if res.CancelReason != tasks.CancelNone {
+		e.Reason = res.CancelReason.String()
+	}
+	if tracing {
+		e.PayloadBefore, e.PayloadAfter = before, o.snapshotPayload(ctx, t.ID, t.Payload)
+	}
+	o.emit(e)
+	return res
+}
+
+// executeWithRetry runs t on a, retrying failures as a's retry policy (or the
+// global one) allows. Contract violations and budget refusals are never
+// retried.
+func (o *Orchestrator) executeWithRetry(ctx context.Context, a agents.Agent, t tasks.Task) tasks.Result {
+	policy := o.Retry
+	if r, ok := agents.As[agents.Retrier](a); ok {
+		policy = r.RetryPolicy()
+	}
+	for attempt := 1; ; attempt++ {
+		res := o.executeOn(ctx, a, t)
+		if res.Status != agents.StatusFailed || errors.Is(res.Err, ErrContractViolation) ||
+			errors.Is(res.Err, ErrBudgetExceeded) || !policy.Retryable(attempt, res.Err) {
+			return res
+		}
+		select {
+		case <-ctx.Done():
+			return res
+		case <-time.After(policy.Delay(attempt)):
+		}
+	}
+}
+
+// executeOn runs t on a, applying timeouts, limits and result checks.
+func (o *Orchestrator) executeOn(ctx context.Context, a agents.Agent, t tasks.Task) tasks.Result {
+	if err := o.charge(a, t); err != nil {
+		return failed(t.ID, err)
+	}
+	o.emitTask(EventStarted, t, a.Name(), "")
+
+	if o.TaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.TaskTimeout)
+		defer cancel()
+	}
+	ctx = agents.WithIdempotencyKey(ctx, agents.IdempotencyKeyFor(t.ID))
+	at := agents.Task{ID: t.ID, Type: t.Type, Payload: t.Payload}
+	run := a
+	if da, ok := agents.As[agents.DeltaAgent](a); ok {
+		run = o.deltaAgent(ctx, da, t)
+	} else if sa, ok := agents.As[agents.StreamingAgent](a); ok {
+		run = agents.Streamed(sa, o.chunkSender(t, a.Name()))
+	}
+	var r agents.Result
+	var err error
+	timeout := o.TaskTimeout
+	began := time.Now()
+	if l, ok := o.limitsFor(a); ok {
+		if l.Timeout > 0 && (timeout <= 0 || l.Timeout < timeout) {
+			timeout = l.Timeout
+		}
+		r, err = agents.RunLimited(ctx, run, at, l)
+	} else {
+		r, err = run.Execute(ctx, at)
+	}
+	if eo, ok := o.Registry.(executionObserver); ok {
+		eo.ObserveExecution(a.Name(), time.Since(began), timeout)
+	}
+	if serr := o.checkSize(t, r); serr != nil {
+		// Drop the oversized output so it never reaches stores or transports.
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusFailed, Err: serr}
+	}
+	if err == nil {
+		if verr := o.checkResult(t, r); verr != nil {
+			return tasks.Result{TaskID: t.ID, Status: agents.StatusFailed, Output: r.Output, Err: verr}
+		}
+	}
+
+	switch {
+	case r.Status == agents.StatusPartial:
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusPartial, Output: r.Output, Err: err}
+	case err != nil:
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusFailed, Output: r.Output, Err: err}
+	case r.Status == agents.StatusFailed:
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusFailed, Output: r.Output, Err: errors.New(r.Error)}
+	}
+	return tasks.Result{TaskID: t.ID, Status: agents.StatusOK, Output: r.Output}
+}
+
+// acquireAgent selects an agent and, if the registry tracks capacity, reserves
+// a slot on it. Another worker may take the last slot between selection and
+// reservation, so selection is retried a few times.
+func (o *Orchestrator) acquireAgent(ctx context.Context, t tasks.Task) (agents.Agent, bool) {
+	ct, tracked := o.Registry.(capacityTracker)
+	ws, canWait := o.Registry.(waitSelector)
+	canWait = canWait && o.WaitForAgent
+	cn, _ := o.Registry.(changeNotifier)
+	for attempt := 0; canWait || attempt < 3; attempt++ {
+		var changed <-chan struct{}
+		if canWait && cn != nil {
+			changed = cn.Changed()
+		}
+		a, ok := o.selectAgent(t)
+		if ok {
+			a = o.spread(t, a)
+		}
+		if !ok && changed != nil {
+			// Re-run the full selection, payload affinity included, on wake-up.
+			select {
+			case <-ctx.Done():
+				return nil, false
+			case <-changed:
+				continue
+			}
+		}
+		if !ok && canWait {
+			var err error
+			if a, err = ws.SelectWait(ctx, t.Type); err != nil {
+				return nil, false
+			}
+			ok = true
+		}
+		if !ok {
+			return nil, false
+		}
+		if !tracked || ct.AcquireFor(a.Name(), t.Type) {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// selectAgent prefers suitability-ranked selection when the registry has it,
+// then payload-aware selection, then plain selection by type.
+func (o *Orchestrator) selectAgent(t tasks.Task) (agents.Agent, bool) {
+	if bs, ok := o.Registry.(bestSelector); ok {
+		return bs.SelectBest(t)
+	}
+	if ts, ok := o.Registry.(taskSelector); ok {
+		return ts.SelectTask(t)
+	}
+	return o.Registry.Select(t.Type)
+}
+
+func (o *Orchestrator) limitsFor(a agents.Agent) (agents.Limits, bool) {
+	lp, ok := o.Registry.(limitsProvider)
+	if !ok {
+		return agents.Limits{}, false
+	}
+	l, ok := lp.Limits(a.Name())
+	return l, ok && l != (agents.Limits{})
+}
+
+// complete acks t, stores and publishes its result and wakes whoever waits
+// on it.
+func (o *Orchestrator) complete(ctx context.Context, t tasks.Task, res tasks.Result) {
+	res = o.transform(ctx, res)
+	if err := o.Queue.Ack(ctx, res.TaskID, res); err != nil && res.Err == nil {
+		res = failed(res.TaskID, err)
+	}
+	if o.Results != nil {
+		o.Results.Put(ctx, res)
+	}
+	o.dropCheckpoint(ctx, res.TaskID)
+	if !o.failed.holds(t.ID) {
+		o.releaseBlobs(ctx, t) // else RequeueModified still needs them
+	}
+	o.publish(ctx, t, res)
+	o.load.record(res.Status == agents.StatusFailed)
+	o.groupResult(res)
+	o.signal(res)
+}
+
+// signal hands res to whoever waits on its task.
+func (o *Orchestrator) signal(res tasks.Result) {
+	o.mu.Lock()
+	chans := o.waiters[res.TaskID]
+	delete(o.waiters, res.TaskID)
+	o.mu.Unlock()
+	for _, ch := range chans {
+		ch <- res // buffered, one send per channel
+	}
+}
+
+// subscribe returns a channel that receives taskID's result once it completes.
+func (o *Orchestrator) subscribe(taskID string) chan tasks.Result {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.waiters == nil {
+		o.waiters = make(map[string][]chan tasks.Result)
+	}
+	ch := make(chan tasks.Result, 1)
+	o.waiters[taskID] = append(o.waiters[taskID], ch)
+	return ch
+}
+
+// unsubscribe drops ch if it has not been signalled yet.
+func (o *Orchestrator) unsubscribe(taskID string, ch chan tasks.Result) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	list := o.waiters[taskID]
+	for i, c := range list {
+		if c == ch {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(o.waiters, taskID)
+	} else {
+		o.waiters[taskID] = list
+	}
+}
+
+func (o *Orchestrator) await(runID string, plan []tasks.Task) []chan tasks.Result {
+	o.mu.Lock()
+	if o.taskRuns == nil {
+		o.taskRuns = make(map[string]string)
+	}
+	for _, t := range plan {
+		o.taskRuns[t.ID] = runID
+	}
+	o.mu.Unlock()
+
+	out := make([]chan tasks.Result, len(plan))
+	for i, t := range plan {
+		out[i] = o.subscribe(t.ID)
+	}
+	return out
+}
+
+func (o *Orchestrator) forget(plan []tasks.Task, waits []chan tasks.Result) {
+	for i, t := range plan {
+		o.unsubscribe(t.ID, waits[i])
+	}
+	o.mu.Lock()
+	for _, t := range plan {
+		delete(o.taskRuns, t.ID)
+	}
+	o.mu.Unlock()
+}
+
+// runOf returns the ID of the Run a task belongs to, if any.
+func (o *Orchestrator) runOf(taskID string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.taskRuns[taskID]
+}
+
+func failed(taskID string, err error) tasks.Result {
+	return tasks.Result{TaskID: taskID, Status: agents.StatusFailed, Err: err}
+}
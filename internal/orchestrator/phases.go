@@ -0,0 +1,31 @@
+package orchestrator
+
+// Phased planning: later tasks depend on what earlier ones found
+
+import (
+	"context"
+
+	"github.com/ngx-workshop/mcp-server/internal/criteria"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// PhasedPlanner plans a run in phases, e.g. diagnostic tasks first, then
+// remediation or enrichment depending on their results. Run calls NextPhase
+// with the results of every earlier phase (nil for the first), executes the
+// returned tasks and repeats until done is true or a phase has no tasks; the
+// tasks returned with done still run. Tasks that never ran, e.g. because the
+// run timed out, have no result in prior.
+type PhasedPlanner interface {
+	NextPhase(ctx context.Context, c criteria.Criteria, prior []tasks.Result) (next []tasks.Task, done bool, err error)
+}
+
+// Phased turns p into a Planner for Orchestrator.Planner. Its Plan returns
+// only the first phase, for callers that plan without running.
+func Phased(p PhasedPlanner) Planner { return phased{p} }
+
+type phased struct{ PhasedPlanner }
+
+func (p phased) Plan(ctx context.Context, c criteria.Criteria) ([]tasks.Task, error) {
+	next, _, err := p.NextPhase(ctx, c, nil)
+	return next, err
+}
@@ -0,0 +1,214 @@
+package orchestrator
+
+// Per-task-type worker pools
+// Task types listed in TypeWorkers run on executors of their own, so a flood
+// of slow tasks of one type cannot take the workers other types need.
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// typeDequeuer is implemented by queues that can hand out only the tasks a
+// caller accepts, like *tasks.MemoryQueue. TypeWorkers needs one.
+type typeDequeuer interface {
+	DequeueMatching(ctx context.Context, match func(tasks.Task) bool) (tasks.Task, error)
+}
+
+// typePool is the dedicated executors of one task type. Each executor
+// dequeues a task of the type only once it is free, so tasks beyond the
+// pool's size wait in the queue, in priority order, like any other. Only
+// tasks its tenant slots pass on, already dequeued, are handed over.
+type typePool struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	wake     chan struct{} // closed and replaced on every handover
+	handover []*parkedTask
+	closed   bool
+}
+
+// workerPools holds the type pools of one startWorkers call.
+type workerPools struct {
+	byType    map[string]*typePool
+	dequeuing sync.WaitGroup // executors still pulling from the queue
+	pending   sync.WaitGroup // tasks handed over and not yet completed
+	workers   sync.WaitGroup
+}
+
+// pooledTypes returns the TypeWorkers entries that get a pool: those with a
+// positive size, and none if the queue cannot dequeue by type.
+func (o *Orchestrator) pooledTypes() map[string]int {
+	if _, ok := o.Queue.(typeDequeuer); !ok {
+		return nil
+	}
+	out := make(map[string]int, len(o.TypeWorkers))
+	for typ, size := range o.TypeWorkers {
+		if size > 0 {
+			out[typ] = size
+		}
+	}
+	return out
+}
+
+// capacity is the number of concurrent executions Workers and TypeWorkers
+// allow together.
+func (o *Orchestrator) capacity() int {
+	n := max(o.Workers, 1)
+	for _, size := range o.pooledTypes() {
+		n += size
+	}
+	return n
+}
+
+// sharedMatch accepts the tasks not served by a type pool; nil when there
+// are no pools.
+func (wp *workerPools) sharedMatch() func(tasks.Task) bool {
+	if len(wp.byType) == 0 {
+		return nil
+	}
+	return func(t tasks.Task) bool {
+		_, pooled := wp.byType[t.Type]
+		return !pooled
+	}
+}
+
+// startPools launches the executors of every pool. They dequeue tasks of
+// their type until stop ends or the queue says there are no more, reporting
+// the error that ended it to failed, and then run only handed-over tasks
+// until stop is called.
+func (o *Orchestrator) startPools(stop, ctx context.Context, failed func(error)) *workerPools {
+	wp := &workerPools{byType: make(map[string]*typePool)}
+	td, _ := o.Queue.(typeDequeuer)
+	for typ, size := range o.pooledTypes() {
+		p := &typePool{wake: make(chan struct{})}
+		p.cond = sync.NewCond(&p.mu)
+		wp.byType[typ] = p
+		match := func(t tasks.Task) bool { return t.Type == typ }
+		for i := 0; i < size; i++ {
+			wp.workers.Add(1)
+			wp.dequeuing.Add(1)
+			go func() {
+				defer wp.workers.Done()
+				for dequeuing := true; ; {
+					if pt, ok := p.take(!dequeuing); ok {
+						o.work(pt.ctx, pt.t, wp, p)
+						wp.pending.Done()
+						continue
+					} else if !dequeuing {
+						return // closed
+					}
+					t, err := p.dequeue(stop, td, match)
+					switch {
+					case err == nil:
+						if o.admitTenant(ctx, t) {
+							o.work(ctx, t, wp, p)
+						}
+					case stop.Err() == nil && errors.Is(err, errHandover):
+					default:
+						failed(err)
+						dequeuing = false
+						wp.dequeuing.Done()
+					}
+				}
+			}()
+		}
+	}
+	return wp
+}
+
+// errHandover interrupts an executor's dequeue when a task is handed over.
+var errHandover = errors.New("task handed over")
+
+// dequeue waits for a queued task of the pool's type, returning errHandover
+// as soon as the pool has a handed-over task instead.
+func (p *typePool) dequeue(stop context.Context, td typeDequeuer, match func(tasks.Task) bool) (tasks.Task, error) {
+	ctx, cancel := context.WithCancelCause(stop)
+	defer cancel(nil)
+	p.mu.Lock()
+	wake, waiting := p.wake, len(p.handover) > 0
+	p.mu.Unlock()
+	if waiting {
+		return tasks.Task{}, errHandover
+	}
+	go func() {
+		select {
+		case <-wake:
+			cancel(errHandover)
+		case <-ctx.Done():
+		}
+	}()
+	t, err := td.DequeueMatching(ctx, match)
+	if err != nil && stop.Err() == nil && context.Cause(ctx) == errHandover {
+		return tasks.Task{}, errHandover
+	}
+	return t, err
+}
+
+// work executes t on pool (nil: the shared one) and then every task its
+// tenant slot passes to, as long as they belong to the same pool.
+func (o *Orchestrator) work(ctx context.Context, t tasks.Task, wp *workerPools, pool *typePool) {
+	for {
+		o.runTask(ctx, t)
+		p, ok := o.releaseTenant(t)
+		if !ok {
+			return
+		}
+		t, ctx = p.t, p.ctx
+		if next := wp.byType[t.Type]; next != pool {
+			if next == nil {
+				// A shared-type task freed by a pooled one; run it here
+				// rather than waiting for a shared worker to come free.
+				pool = nil
+				continue
+			}
+			wp.submit(next, t, ctx)
+			return
+		}
+	}
+}
+
+// stop waits for the executors to stop dequeuing and for every handed-over
+// task to complete, then for the executors to exit. Call it once the shared
+// dequeue loops have stopped.
+func (wp *workerPools) stop() {
+	wp.dequeuing.Wait()
+	wp.pending.Wait()
+	for _, p := range wp.byType {
+		p.mu.Lock()
+		p.closed = true
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}
+	wp.workers.Wait()
+}
+
+// submit hands a dequeued task to p, waking an idle executor.
+func (wp *workerPools) submit(p *typePool, t tasks.Task, ctx context.Context) {
+	wp.pending.Add(1)
+	p.mu.Lock()
+	p.handover = append(p.handover, &parkedTask{t: t, ctx: ctx})
+	close(p.wake)
+	p.wake = make(chan struct{})
+	p.cond.Signal()
+	p.mu.Unlock()
+}
+
+// take returns the oldest handed-over task. With wait set it blocks until
+// there is one or the pool is closed.
+func (p *typePool) take(wait bool) (*parkedTask, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for wait && len(p.handover) == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if len(p.handover) == 0 {
+		return nil, false
+	}
+	pt := p.handover[0]
+	p.handover[0] = nil
+	p.handover = p.handover[1:]
+	return pt, true
+}
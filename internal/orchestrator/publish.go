@@ -0,0 +1,173 @@
+package orchestrator
+
+// Result publishing to external pipelines (e.g. Kafka for analytics)
+// Every acked result is handed to a ResultPublisher. AsyncPublisher keeps slow
+// or failing backends off the workers' path.
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// DefaultPublishBuffer is the AsyncPublisher buffer when none is given.
+const DefaultPublishBuffer = 1024
+
+// DefaultPublishRetry is the AsyncPublisher retry policy when none is given.
+var DefaultPublishRetry = agents.RetryPolicy{MaxAttempts: 5, Backoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+var ErrPublisherClosed = errors.New("result publisher is closed")
+
+// PublishedResult is the serialized form of a completed task's result.
+type PublishedResult struct {
+	TaskID       string         `json:"taskId"`
+	TaskType     string         `json:"taskType"`
+	TenantID     string         `json:"tenantId,omitempty"`
+	RunID        string         `json:"runId,omitempty"`
+	Status       string         `json:"status"`
+	Output       map[string]any `json:"output,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	CancelReason string         `json:"cancelReason,omitempty"`
+	At           time.Time      `json:"at"`
+}
+
+// ResultPublisher sends completed results to an external system. The
+// orchestrator calls Publish on the worker that acked the result, so it must
+// return quickly; wrap backends that may not in an AsyncPublisher.
+type ResultPublisher interface {
+	Publish(ctx context.Context, r PublishedResult) error
+}
+
+// NopPublisher discards every result; it is what an orchestrator without a
+// Publisher does.
+type NopPublisher struct{}
+
+func (NopPublisher) Publish(context.Context, PublishedResult) error { return nil }
+
+// AsyncPublisher publishes through Publisher from a background goroutine,
+// retrying failures per Retry. Publish only queues the result: when the
+// bounded buffer is full, or a result runs out of retries, it is dropped
+// and counted instead of holding up the caller.
+type AsyncPublisher struct {
+	Publisher ResultPublisher
+	Retry     agents.RetryPolicy
+
+	queue   chan PublishedResult
+	done    chan struct{}
+	stop    context.CancelFunc
+	mu      sync.RWMutex // guards closed against concurrent Publish
+	closed  bool
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// NewAsyncPublisher starts publishing through p with a buffer of buffer
+// results (DefaultPublishBuffer if <= 0). A zero retry means
+// DefaultPublishRetry. Call Close to flush and stop it.
+func NewAsyncPublisher(p ResultPublisher, buffer int, retry agents.RetryPolicy) *AsyncPublisher {
+	if buffer <= 0 {
+		buffer = DefaultPublishBuffer
+	}
+	if retry.MaxAttempts == 0 {
+		retry = DefaultPublishRetry
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	a := &AsyncPublisher{
+		Publisher: p, Retry: retry,
+		queue: make(chan PublishedResult, buffer),
+		done:  make(chan struct{}),
+		stop:  stop,
+	}
+	go a.loop(ctx)
+	return a
+}
+
+// Publish queues r and returns at once. It fails with ErrPublisherClosed
+// after Close; a full buffer drops r without an error, see Dropped.
+func (a *AsyncPublisher) Publish(_ context.Context, r PublishedResult) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrPublisherClosed
+	}
+	select {
+	case a.queue <- r:
+	default:
+		a.dropped.Add(1)
+	}
+	return nil
+}
+
+// Dropped counts results lost to a full buffer; Failed those that still
+// failed after every retry.
+func (a *AsyncPublisher) Dropped() uint64 { return a.dropped.Load() }
+func (a *AsyncPublisher) Failed() uint64  { return a.failed.Load() }
+
+// Close stops accepting results and waits for the buffered ones to be
+// published until ctx is done; then in-progress retries are abandoned.
+func (a *AsyncPublisher) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		a.stop()
+		<-a.done
+		return ctx.Err()
+	}
+}
+
+func (a *AsyncPublisher) loop(ctx context.Context) {
+	defer close(a.done)
+	for r := range a.queue {
+		if ctx.Err() != nil {
+			a.failed.Add(1)
+			continue
+		}
+		if a.send(ctx, r) != nil {
+			a.failed.Add(1)
+		}
+	}
+}
+
+func (a *AsyncPublisher) send(ctx context.Context, r PublishedResult) error {
+	for attempt := 1; ; attempt++ {
+		err := a.Publisher.Publish(ctx, r)
+		if !a.Retry.Retryable(attempt, err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(a.Retry.Delay(attempt)):
+		}
+	}
+}
+
+// publish hands t's final result to o.Publisher, if any. A failure is not
+// retried here, only counted; AsyncPublisher is what retries.
+func (o *Orchestrator) publish(ctx context.Context, t tasks.Task, res tasks.Result) {
+	if o.Publisher == nil {
+		return
+	}
+	r := PublishedResult{
+		TaskID: res.TaskID, TaskType: t.Type, TenantID: t.TenantID, RunID: o.runOf(t.ID),
+		Status: res.Status, Output: res.Output, Error: errString(res.Err), At: time.Now(),
+	}
+	if res.CancelReason != tasks.CancelNone {
+		r.CancelReason = res.CancelReason.String()
+	}
+	if err := o.Publisher.Publish(ctx, r); err != nil {
+		o.counters.publishFailed()
+	}
+}
@@ -0,0 +1,113 @@
+package orchestrator
+
+// Operator-driven requeue of failed tasks with a corrected payload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+var ErrNotRetained = errors.New("task is not a retained failed task")
+
+// failedTable keeps the most recent failed tasks, see RetainFailed.
+type failedTable struct {
+	mu    sync.Mutex
+	order []string // oldest first
+	tasks map[string]tasks.Task
+}
+
+// retainFailed remembers t for RequeueModified, evicting the oldest failed
+// task once more than RetainFailed are kept.
+func (o *Orchestrator) retainFailed(t tasks.Task) {
+	if o.RetainFailed <= 0 {
+		return
+	}
+	f := &o.failed
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tasks == nil {
+		f.tasks = make(map[string]tasks.Task)
+	}
+	if _, ok := f.tasks[t.ID]; !ok {
+		f.order = append(f.order, t.ID)
+	}
+	f.tasks[t.ID] = t
+	for len(f.order) > o.RetainFailed {
+		o.releaseBlobs(context.Background(), f.tasks[f.order[0]])
+		delete(f.tasks, f.order[0])
+		f.order = f.order[1:]
+	}
+}
+
+// holds reports whether taskID is retained.
+func (f *failedTable) holds(taskID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.tasks[taskID]
+	return ok
+}
+
+// take removes and returns a retained failed task.
+func (f *failedTable) take(taskID string) (tasks.Task, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, ok := f.tasks[taskID]
+	if !ok {
+		return tasks.Task{}, false
+	}
+	delete(f.tasks, taskID)
+	for i, id := range f.order {
+		if id == taskID {
+			f.order = append(f.order[:i], f.order[i+1:]...)
+			break
+		}
+	}
+	return t, true
+}
+
+// RequeueModified re-enqueues a failed task, retained as RetainFailed
+// allows, as a fresh attempt with a corrected payload. The new task gets a
+// new ID, RequeuedFrom set to taskID and Attempt one past the original's;
+// mutate sees them, so it can note the new ID, but changes to them are
+// undone. The copy leaves the original's group, whose barrier already has
+// the failure, and drops its DedupKey, which the original holds for the
+// dedup window; mutate may set a new one. Someone must be executing tasks
+// (Run or Serve) for the new task to run; its result reaches Results and
+// waiters under the new ID.
+//
+// Unknown, evicted or already requeued tasks fail with ErrNotRetained. If
+// the queue rejects the new task, the original stays retained.
+func (o *Orchestrator) RequeueModified(ctx context.Context, taskID string, mutate func(*tasks.Task)) error {
+	orig, ok := o.failed.take(taskID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotRetained, taskID)
+	}
+	t, err := o.rehydrate(ctx, orig)
+	if err != nil {
+		o.retainFailed(orig)
+		return err
+	}
+	if t.Payload != nil {
+		t.Payload = cloneMap(t.Payload)
+	}
+	t.ID = o.newID()
+	t.RequeuedFrom, t.Attempt = orig.ID, orig.Attempt+1
+	t.GroupID, t.GroupSize, t.DedupKey = "", 0, ""
+	id, from, attempt := t.ID, t.RequeuedFrom, t.Attempt
+	if mutate != nil {
+		mutate(&t)
+	}
+	t.ID, t.RequeuedFrom, t.Attempt = id, from, attempt
+
+	if err := o.enqueue(ctx, &t); err != nil {
+		o.retainFailed(orig)
+		return err
+	}
+	o.releaseBlobs(ctx, orig) // the copy was offloaded anew
+	o.emit(Event{Kind: EventRequeued, TaskID: t.ID, TaskType: t.Type, From: orig.ID, Count: t.Attempt})
+	return nil
+}
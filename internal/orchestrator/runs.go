@@ -0,0 +1,105 @@
+package orchestrator
+
+// Asynchronous runs: submit criteria, poll status by run ID
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/criteria"
+)
+
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// DefaultRetainRuns is how many finished runs are kept when RetainRuns is
+// unset.
+const DefaultRetainRuns = 1000
+
+// RunInfo is a point-in-time view of a submitted run.
+type RunInfo struct {
+	ID         string
+	Status     string
+	Report     Report
+	Err        error
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+type runTable struct {
+	mu       sync.RWMutex
+	runs     map[string]*RunInfo
+	finished []string // IDs of finished runs still in runs, oldest first
+}
+
+// Submit starts Run for c in the background and returns its run ID
+// immediately. The run uses ctx for cancellation, so pass a context that
+// outlives the caller's request. An EventRunDone is emitted once RunStatus
+// reports the outcome.
+func (o *Orchestrator) Submit(ctx context.Context, c criteria.Criteria) (string, error) {
+	id := o.newID()
+	info := &RunInfo{ID: id, Status: RunRunning, StartedAt: time.Now()}
+
+	o.runs.mu.Lock()
+	if o.runs.runs == nil {
+		o.runs.runs = make(map[string]*RunInfo)
+	}
+	o.runs.runs[id] = info
+	o.runs.mu.Unlock()
+
+	go func() {
+		rep, err := o.run(ctx, id, c)
+		o.runs.mu.Lock()
+		info.Report, info.Err, info.FinishedAt = rep, err, time.Now()
+		if err != nil {
+			info.Status = RunFailed
+		} else {
+			info.Status = RunSucceeded
+		}
+		status := info.Status
+		o.runs.retire(id, o.RetainRuns)
+		o.runs.mu.Unlock()
+		o.emit(Event{Kind: EventRunDone, RunID: id, Status: status, Error: errString(err)})
+	}()
+	return id, nil
+}
+
+// SubmitFor is Submit for the criteria stored in Criteria for the learner
+// and course, see RunFor. The criteria are loaded before it returns.
+func (o *Orchestrator) SubmitFor(ctx context.Context, learnerID, courseID string) (string, error) {
+	c, err := o.loadCriteria(ctx, learnerID, courseID)
+	if err != nil {
+		return "", err
+	}
+	return o.Submit(ctx, c)
+}
+
+// RunStatus returns a snapshot of a submitted run.
+func (o *Orchestrator) RunStatus(id string) (RunInfo, bool) {
+	o.runs.mu.RLock()
+	defer o.runs.mu.RUnlock()
+	info, ok := o.runs.runs[id]
+	if !ok {
+		return RunInfo{}, false
+	}
+	return *info, true
+}
+
+// retire records that run id finished and forgets the oldest finished runs
+// beyond keep (DefaultRetainRuns if <= 0). Caller must hold t.mu.
+func (t *runTable) retire(id string, keep int) {
+	if keep <= 0 {
+		keep = DefaultRetainRuns
+	}
+	t.finished = append(t.finished, id)
+	if n := len(t.finished) - keep; n > 0 {
+		for _, old := range t.finished[:n] {
+			delete(t.runs, old)
+		}
+		t.finished = append(t.finished[:0], t.finished[n:]...)
+	}
+}
@@ -0,0 +1,94 @@
+package orchestrator
+
+// Compensation of a failed run's completed tasks (sagas)
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// Compensator undoes the side effects of a task that completed as res.
+type Compensator interface {
+	Compensate(ctx context.Context, t tasks.Task, res tasks.Result) error
+}
+
+// CompensatorFunc adapts a function to Compensator.
+type CompensatorFunc func(ctx context.Context, t tasks.Task, res tasks.Result) error
+
+func (f CompensatorFunc) Compensate(ctx context.Context, t tasks.Task, res tasks.Result) error {
+	return f(ctx, t, res)
+}
+
+// Compensation records one compensating action of a failed run.
+type Compensation struct {
+	TaskID   string
+	TaskType string
+	Err      error // nil when the compensation succeeded
+}
+
+// sagaStep is a task of the run that completed, ok or partial.
+type sagaStep struct {
+	t   tasks.Task
+	res tasks.Result
+}
+
+// recordSteps keeps plan's completed tasks for compensate, in plan order.
+func (r *Report) recordSteps(plan []tasks.Task, results []*tasks.Result) {
+	for i, res := range results {
+		if res != nil && res.Status != agents.StatusFailed {
+			r.steps = append(r.steps, sagaStep{t: plan[i], res: *res})
+		}
+	}
+}
+
+// abandon withdraws the tasks of plan still in the queue, recorded as
+// CancelFailFast in rep.Skipped, after the rest of their phase could not be
+// enqueued, and reports which it withdrew. The others already started; the
+// caller waits for them so that the ones that complete get compensated.
+func (o *Orchestrator) abandon(ctx context.Context, plan []tasks.Task, rep *Report) []bool {
+	wctx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	cancel(Cancelled(tasks.CancelFailFast))
+	withdrawn := make([]bool, len(plan))
+	for i, t := range plan {
+		if o.withdraw(wctx, t.ID) {
+			withdrawn[i] = true
+			rep.Skipped = append(rep.Skipped, t.ID)
+		}
+	}
+	return withdrawn
+}
+
+// compensate runs the Compensations of every completed task of a failed run,
+// latest phase first and in reverse plan order within a phase, and records
+// each in rep. A failing compensation does not stop the others. They run
+// even when the run's context has ended, each bounded by TaskTimeout.
+func (o *Orchestrator) compensate(ctx context.Context, rep *Report) {
+	ctx = context.WithoutCancel(ctx)
+	for i := len(rep.steps) - 1; i >= 0; i-- {
+		s := rep.steps[i]
+		c, ok := o.Compensations[s.t.Type]
+		if !ok {
+			continue
+		}
+		err := o.runCompensation(ctx, c, s)
+		rep.Compensations = append(rep.Compensations, Compensation{TaskID: s.t.ID, TaskType: s.t.Type, Err: err})
+		o.emit(Event{Kind: EventCompensated, RunID: rep.RunID, TaskID: s.t.ID, TaskType: s.t.Type, Error: errString(err)})
+	}
+}
+
+func (o *Orchestrator) runCompensation(ctx context.Context, c Compensator, s sagaStep) (err error) {
+	if o.TaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.TaskTimeout)
+		defer cancel()
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("compensation panicked: %v", p)
+		}
+	}()
+	return c.Compensate(ctx, s.t, s.res)
+}
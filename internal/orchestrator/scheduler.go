@@ -0,0 +1,235 @@
+package orchestrator
+
+// Cron-scheduled runs
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/criteria"
+)
+
+var ErrDuplicateSchedule = errors.New("schedule already exists")
+
+// Clock is the time source of a Scheduler; tests substitute a fake one.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Overlap decides what happens when a schedule fires while its previous run
+// is still going.
+type Overlap int
+
+const (
+	OverlapSkip  Overlap = iota // drop the new run
+	OverlapQueue                // start it once the previous run ends; at most one waits
+)
+
+// CriteriaFunc produces the criteria for one scheduled run.
+type CriteriaFunc func(ctx context.Context) (criteria.Criteria, error)
+
+// ScheduledRun is what a Scheduler reports after every run it triggered.
+type ScheduledRun struct {
+	Name    string
+	Fired   time.Time // when the schedule was due, before jitter
+	Report  Report
+	Err     error // from the CriteriaFunc or Run
+	Skipped bool  // OverlapSkip dropped this firing; nothing ran
+}
+
+// Scheduler triggers Orchestrator runs on cron schedules. Schedules can be
+// added and removed while it is running.
+type Scheduler struct {
+	Orchestrator *Orchestrator
+	Clock        Clock         // defaults to the system clock
+	Jitter       time.Duration // each run starts up to Jitter late, at random
+	// OnRun, when set, is called after every firing; it must be safe for
+	// concurrent use.
+	OnRun func(ScheduledRun)
+
+	mu      sync.Mutex
+	entries map[string]*schedule
+	changed chan struct{} // closed and replaced on Add and Remove
+	wg      sync.WaitGroup
+}
+
+type schedule struct {
+	name    string
+	spec    CronSpec
+	produce CriteriaFunc
+	overlap Overlap
+	next    time.Time
+	running bool
+	queued  bool
+	removed bool
+}
+
+// NewScheduler creates a scheduler for o.
+func NewScheduler(o *Orchestrator) *Scheduler {
+	return &Scheduler{Orchestrator: o}
+}
+
+func (s *Scheduler) clock() Clock {
+	if s.Clock == nil {
+		return realClock{}
+	}
+	return s.Clock
+}
+
+// Add registers a schedule. expr is parsed with ParseCron; produce is called
+// at every firing for the criteria to run.
+func (s *Scheduler) Add(name, expr string, produce CriteriaFunc, overlap Overlap) error {
+	spec, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]*schedule)
+	}
+	if _, ok := s.entries[name]; ok {
+		return ErrDuplicateSchedule
+	}
+	s.entries[name] = &schedule{
+		name: name, spec: spec, produce: produce, overlap: overlap,
+		next: spec.Next(s.clock().Now()),
+	}
+	s.signal()
+	return nil
+}
+
+// Remove deletes a schedule. A run already going finishes; a queued one is
+// dropped.
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	if ok {
+		e.removed = true
+		delete(s.entries, name)
+		s.signal()
+	}
+	return ok
+}
+
+// signal wakes Start to recompute its timer. Caller must hold s.mu.
+func (s *Scheduler) signal() {
+	if s.changed != nil {
+		close(s.changed)
+	}
+	s.changed = make(chan struct{})
+}
+
+// Start fires schedules until ctx is done, then waits for the runs it
+// started, whose context is ctx as well, and returns ctx's error.
+func (s *Scheduler) Start(ctx context.Context) error {
+	defer s.wg.Wait()
+	clk := s.clock()
+	for {
+		s.mu.Lock()
+		if s.changed == nil {
+			s.changed = make(chan struct{})
+		}
+		changed := s.changed
+		var soonest time.Time
+		for _, e := range s.entries {
+			if !e.next.IsZero() && (soonest.IsZero() || e.next.Before(soonest)) {
+				soonest = e.next
+			}
+		}
+		s.mu.Unlock()
+
+		var timer <-chan time.Time
+		if !soonest.IsZero() {
+			timer = clk.After(max(soonest.Sub(clk.Now()), 0))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+			continue
+		case <-timer:
+		}
+		s.fireDue(ctx, clk.Now())
+	}
+}
+
+// fireDue triggers every schedule due at now and advances it. Skipped
+// firings are reported once s.mu is released, so OnRun may call Add or
+// Remove.
+func (s *Scheduler) fireDue(ctx context.Context, now time.Time) {
+	var skipped []ScheduledRun
+	defer func() {
+		for _, r := range skipped {
+			s.report(r)
+		}
+	}()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+		fired := e.next
+		e.next = e.spec.Next(now)
+		switch {
+		case !e.running:
+			e.running = true
+			s.wg.Add(1)
+			go s.run(ctx, e, fired, true)
+		case e.overlap == OverlapQueue:
+			e.queued = true
+		default:
+			skipped = append(skipped, ScheduledRun{Name: e.name, Fired: fired, Skipped: true})
+		}
+	}
+}
+
+// run executes e, then any firing queued meanwhile.
+func (s *Scheduler) run(ctx context.Context, e *schedule, fired time.Time, jitter bool) {
+	defer s.wg.Done()
+	for {
+		if jitter && s.Jitter > 0 {
+			select {
+			case <-ctx.Done():
+			case <-s.clock().After(rand.N(s.Jitter)):
+			}
+		}
+		out := ScheduledRun{Name: e.name, Fired: fired}
+		if ctx.Err() != nil {
+			out.Err = ctx.Err()
+		} else if c, err := e.produce(ctx); err != nil {
+			out.Err = err
+		} else {
+			out.Report, out.Err = s.Orchestrator.Run(ctx, c)
+		}
+		s.report(out)
+
+		s.mu.Lock()
+		if !e.queued || e.removed || ctx.Err() != nil {
+			e.running, e.queued = false, false
+			s.mu.Unlock()
+			return
+		}
+		e.queued = false
+		fired = s.clock().Now()
+		s.mu.Unlock()
+		jitter = false // it already waited its turn
+	}
+}
+
+func (s *Scheduler) report(r ScheduledRun) {
+	if s.OnRun != nil {
+		s.OnRun(r)
+	}
+}
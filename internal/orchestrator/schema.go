@@ -0,0 +1,101 @@
+package orchestrator
+
+// Output schemas: the fields and types downstream consumers rely on
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// FieldType is the JSON-level type an output field must have.
+type FieldType string
+
+const (
+	FieldString FieldType = "string"
+	FieldNumber FieldType = "number" // any Go integer or float
+	FieldBool   FieldType = "bool"
+	FieldObject FieldType = "object" // map[string]any
+	FieldArray  FieldType = "array"  // []any or a typed slice of strings, numbers or maps
+	FieldAny    FieldType = ""
+)
+
+// FieldSpec describes one Output field.
+type FieldSpec struct {
+	Type     FieldType
+	Optional bool // may be absent; when present it must still have Type
+}
+
+// OutputSchema maps Output field names to their specs.
+type OutputSchema map[string]FieldSpec
+
+// Validate reports every field of out that is missing or has the wrong type,
+// in field order.
+func (s OutputSchema) Validate(out map[string]any) error {
+	return s.validate(out, "output")
+}
+
+// validate is Validate, naming fields as what's in errors.
+func (s OutputSchema) validate(out map[string]any, what string) error {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		spec := s[name]
+		v, ok := out[name]
+		switch {
+		case !ok && !spec.Optional:
+			errs = append(errs, fmt.Errorf("missing %s field %q", what, name))
+		case ok && !spec.Type.matches(v):
+			errs = append(errs, fmt.Errorf("%s field %q is %T, want %s", what, name, v, spec.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (ft FieldType) matches(v any) bool {
+	switch ft {
+	case FieldAny:
+		return true
+	case FieldString:
+		_, ok := v.(string)
+		return ok
+	case FieldBool:
+		_, ok := v.(bool)
+		return ok
+	case FieldNumber:
+		_, ok := number(v)
+		if !ok {
+			switch v.(type) {
+			case int8, int16, int32, uint, uint8, uint16, uint32, uint64:
+				ok = true
+			}
+		}
+		return ok
+	case FieldObject:
+		_, ok := v.(map[string]any)
+		return ok
+	case FieldArray:
+		switch v.(type) {
+		case []any, []string, []float64, []int, []map[string]any:
+			return true
+		}
+	}
+	return false
+}
+
+// checkSchema validates an ok result against the schema registered for its
+// task type. Types without a schema are not checked.
+func (o *Orchestrator) checkSchema(taskType string, out map[string]any) error {
+	s, ok := o.OutputSchemas[taskType]
+	if !ok {
+		return nil
+	}
+	if err := s.Validate(out); err != nil {
+		return fmt.Errorf("%w: %s output does not match its schema: %w", ErrContractViolation, taskType, err)
+	}
+	return nil
+}
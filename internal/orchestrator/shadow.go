@@ -0,0 +1,126 @@
+package orchestrator
+
+// Shadow execution: dry-run new agents next to the primary path
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// shadowProvider is implemented by registries holding shadow agents, like
+// *agents.Registry.
+type shadowProvider interface {
+	Shadows(taskType string) []agents.Agent
+}
+
+// ShadowComparison pairs a shadow's result with the primary's for one task.
+type ShadowComparison struct {
+	TaskID   string
+	TaskType string
+	Primary  string // agent names
+	Shadow   string
+	Want     tasks.Result // what the primary returned and the run used
+	Got      tasks.Result // what the shadow returned; never acked or stored
+	Match    bool         // same status and output
+	Elapsed  time.Duration
+}
+
+// ShadowRecorder receives comparisons from shadow executions. Like EventLog,
+// it is called from background goroutines and must be safe for concurrent use.
+type ShadowRecorder interface {
+	RecordShadow(c ShadowComparison)
+}
+
+// startShadows runs every shadow registered for t.Type in the background on
+// a deep copy of the payload. Call the returned function with the primary's
+// result; it returns immediately and the comparisons are recorded once the
+// shadows finish. Shadows bypass capacity tracking, and their errors, panics
+// and latency never reach the primary path.
+func (o *Orchestrator) startShadows(ctx context.Context, primary agents.Agent, t tasks.Task) func(tasks.Result) {
+	sp, ok := o.Registry.(shadowProvider)
+	if !ok || o.Shadows == nil {
+		return func(tasks.Result) {}
+	}
+	shadows := sp.Shadows(t.Type)
+	if len(shadows) == 0 {
+		return func(tasks.Result) {}
+	}
+	want := make(chan tasks.Result, 1)
+	for _, s := range shadows {
+		st := t
+		st.Payload = deepCopy(t.Payload) // before the primary can touch it
+		go func() {
+			began := time.Now()
+			got := o.runShadow(ctx, s, st)
+			elapsed := time.Since(began)
+			w := <-want
+			want <- w // leave it for the other shadows
+			o.Shadows.RecordShadow(ShadowComparison{
+				TaskID: t.ID, TaskType: t.Type, Primary: primary.Name(), Shadow: s.Name(),
+				Want: w, Got: got, Elapsed: elapsed,
+				Match: w.Status == got.Status && reflect.DeepEqual(w.Output, got.Output),
+			})
+		}()
+	}
+	return func(res tasks.Result) { want <- res }
+}
+
+// runShadow executes one shadow attempt and turns a panic into a failure.
+func (o *Orchestrator) runShadow(ctx context.Context, a agents.Agent, t tasks.Task) (res tasks.Result) {
+	defer func() {
+		if p := recover(); p != nil {
+			res = failed(t.ID, fmt.Errorf("shadow agent %s panicked: %v", a.Name(), p))
+		}
+	}()
+	if o.TaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.TaskTimeout)
+		defer cancel()
+	}
+	// Shadows get no idempotency key: they must not reach real side effects
+	// under the primary's key.
+	at := agents.Task{ID: t.ID, Type: t.Type, Payload: t.Payload}
+	r, err := a.Execute(ctx, at)
+	switch {
+	case r.Status == agents.StatusPartial:
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusPartial, Output: r.Output, Err: err}
+	case err != nil:
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusFailed, Output: r.Output, Err: err}
+	case r.Status == agents.StatusFailed:
+		return tasks.Result{TaskID: t.ID, Status: agents.StatusFailed, Output: r.Output, Err: errors.New(r.Error)}
+	}
+	return tasks.Result{TaskID: t.ID, Status: agents.StatusOK, Output: r.Output}
+}
+
+// deepCopy clones nested maps and slices so a shadow cannot modify the
+// payload the primary works on.
+func deepCopy(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = deepCopyValue(v)
+	}
+	return out
+}
+
+func deepCopyValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		return deepCopy(x)
+	case []any:
+		out := make([]any, len(x))
+		for i, e := range x {
+			out[i] = deepCopyValue(e)
+		}
+		return out
+	}
+	return v
+}
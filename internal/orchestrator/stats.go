@@ -0,0 +1,70 @@
+package orchestrator
+
+// Execution counters
+
+import (
+	"sync"
+	"time"
+)
+
+// ExecStats counts finished executions since the orchestrator was created.
+type ExecStats struct {
+	Executions map[string]map[string]uint64 // task type -> result status -> count
+	Seconds    map[string]float64           // task type -> total execution time
+
+	// PublishFailures counts results the Publisher returned an error for.
+	PublishFailures uint64
+}
+
+type execCounters struct {
+	mu           sync.Mutex
+	executions   map[string]map[string]uint64
+	seconds      map[string]float64
+	publishFails uint64 // see ExecStats.PublishFailures
+}
+
+func (c *execCounters) record(taskType, status string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.executions == nil {
+		c.executions = make(map[string]map[string]uint64)
+		c.seconds = make(map[string]float64)
+	}
+	byStatus := c.executions[taskType]
+	if byStatus == nil {
+		byStatus = make(map[string]uint64)
+		c.executions[taskType] = byStatus
+	}
+	byStatus[status]++
+	c.seconds[taskType] += d.Seconds()
+}
+
+func (c *execCounters) publishFailed() {
+	c.mu.Lock()
+	c.publishFails++
+	c.mu.Unlock()
+}
+
+// Stats returns a copy of the execution counters.
+func (o *Orchestrator) Stats() ExecStats {
+	c := &o.counters
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := ExecStats{
+		Executions: make(map[string]map[string]uint64, len(c.executions)),
+		Seconds:    make(map[string]float64, len(c.seconds)),
+
+		PublishFailures: c.publishFails,
+	}
+	for t, byStatus := range c.executions {
+		cp := make(map[string]uint64, len(byStatus))
+		for s, n := range byStatus {
+			cp[s] = n
+		}
+		out.Executions[t] = cp
+	}
+	for t, s := range c.seconds {
+		out.Seconds[t] = s
+	}
+	return out
+}
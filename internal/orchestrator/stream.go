@@ -0,0 +1,32 @@
+package orchestrator
+
+// Streaming agent output
+// Chunks from agents.StreamingAgent executions are published on the Bus as
+// EventChunk events, so live views can follow a task before its result is
+// final. They are not recorded in Events: a trace of every chunk would cost
+// a write per chunk and crowd the lifecycle events out of bounded logs.
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// chunkSender returns the send function for one streaming execution of t. It
+// never blocks: the Bus drops chunks for subscribers that fall behind, per
+// its DropPolicy, and the final result still arrives as EventFinished.
+func (o *Orchestrator) chunkSender(t tasks.Task, agent string) func(agents.ResultChunk) {
+	if o.Bus == nil {
+		return func(agents.ResultChunk) {}
+	}
+	runID := o.runOf(t.ID)
+	var seq atomic.Int64
+	return func(c agents.ResultChunk) {
+		c.TaskID = t.ID
+		c.Seq = int(seq.Add(1))
+		e := Event{Kind: EventChunk, Seq: eventSeq.Add(1), At: time.Now(), RunID: runID, TaskID: t.ID, TaskType: t.Type, Agent: agent, Chunk: &c}
+		o.Bus.Publish(string(e.Kind), e)
+	}
+}
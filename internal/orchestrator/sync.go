@@ -0,0 +1,77 @@
+package orchestrator
+
+// Synchronous request/response execution on top of the queue
+
+import (
+	"context"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// ExecuteSync enqueues t and blocks until its result is in, returning it;
+// a failed task is still a nil error, with the failure in the Result. Like
+// EnqueueOrGetResult it relies on Run or Serve executing queued tasks.
+//
+// When ctx ends first ExecuteSync returns ctx's cause. The task is withdrawn
+// if it is still queued, recorded with the reason ctx ended for (see
+// Cancelled); once a worker has it, it runs to completion and its result
+// still reaches Results. Nothing is left waiting on the caller's behalf.
+func (o *Orchestrator) ExecuteSync(ctx context.Context, t tasks.Task) (tasks.Result, error) {
+	o.assignID(&t)
+	// Subscribe before enqueueing so a fast completion is not missed.
+	ch := o.subscribe(t.ID)
+	defer o.unsubscribe(t.ID, ch)
+	if err := o.enqueue(ctx, &t); err != nil {
+		return tasks.Result{}, err
+	}
+	select {
+	case res := <-ch:
+		return res, nil
+	case <-ctx.Done():
+		o.withdraw(ctx, t.ID)
+		return tasks.Result{}, context.Cause(ctx)
+	}
+}
+
+// withdraw drops taskID from the queue if it is still pending there, with the
+// reason the done ctx ended for, and reports whether it did. A withdrawn
+// task's group gets an ErrSkipped result so it does not wait forever.
+func (o *Orchestrator) withdraw(ctx context.Context, taskID string) bool {
+	reason := reasonFor(ctx)
+	var dropped bool
+	switch d := o.Queue.(type) {
+	case reasonDropper:
+		dropped = d.DropReason(taskID, reason)
+	case pendingDropper:
+		dropped = d.Drop(taskID, reason.State())
+	}
+	if dropped {
+		res := failed(taskID, ErrSkipped)
+		res.CancelReason = reason
+		o.groupResult(res)
+	}
+	return dropped
+}
+
+// evictingQueue is implemented by queues that report the tasks an enqueue
+// evicted, like *tasks.MemoryQueue with tasks.WithMaxPending.
+type evictingQueue interface {
+	EnqueueEvicting(ctx context.Context, t tasks.Task) ([]tasks.Eviction, error)
+}
+
+// enqueueEvicting enqueues t and fails whatever it evicted, so that runs,
+// groups and ExecuteSync callers waiting on those tasks are not left hanging.
+func (o *Orchestrator) enqueueEvicting(ctx context.Context, t tasks.Task) error {
+	eq, ok := o.Queue.(evictingQueue)
+	if !ok {
+		return o.Queue.Enqueue(ctx, t)
+	}
+	evicted, err := eq.EnqueueEvicting(ctx, t)
+	for _, e := range evicted {
+		res := failed(e.Task.ID, e.Err())
+		res.CancelReason = e.Reason
+		o.groupResult(res)
+		o.signal(res)
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package orchestrator
+
+// Per-tenant concurrency caps
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// tenantGate bounds concurrent executions per tenant. Tasks over the cap are
+// parked instead of blocking a worker, so one tenant's burst cannot tie up
+// the workers other tenants need.
+type tenantGate struct {
+	mu     sync.Mutex
+	active map[string]int
+	parked map[string][]*parkedTask
+}
+
+type parkedTask struct {
+	t    tasks.Task
+	ctx  context.Context
+	stop func() bool // detaches the cancellation hook
+}
+
+// tenantLimit returns the cap for tenant; zero means unlimited.
+func (o *Orchestrator) tenantLimit(tenant string) int {
+	if tenant == "" {
+		return 0
+	}
+	if n, ok := o.TenantLimits[tenant]; ok {
+		return n
+	}
+	return o.TenantLimit
+}
+
+// admitTenant takes an execution slot for t's tenant and reports true, or
+// parks t until a slot frees up. A parked task whose context ends first is
+// completed as failed with the context's cause and cancel reason.
+func (o *Orchestrator) admitTenant(ctx context.Context, t tasks.Task) bool {
+	limit := o.tenantLimit(t.TenantID)
+	if limit <= 0 {
+		return true
+	}
+	g := &o.tenants
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.active == nil {
+		g.active = make(map[string]int)
+		g.parked = make(map[string][]*parkedTask)
+	}
+	if g.active[t.TenantID] < limit {
+		g.active[t.TenantID]++
+		return true
+	}
+	p := &parkedTask{t: t, ctx: ctx}
+	p.stop = context.AfterFunc(ctx, func() {
+		if g.unpark(p) {
+			res := failed(t.ID, context.Cause(ctx))
+			res.CancelReason = reasonFor(ctx)
+			o.complete(context.WithoutCancel(ctx), t, res)
+		}
+	})
+	g.parked[t.TenantID] = append(g.parked[t.TenantID], p)
+	return false
+}
+
+// releaseTenant frees t's slot. If a task of the same tenant is parked, the
+// slot passes straight to it and it is returned for the caller to run.
+func (o *Orchestrator) releaseTenant(t tasks.Task) (*parkedTask, bool) {
+	if o.tenantLimit(t.TenantID) <= 0 {
+		return nil, false
+	}
+	g := &o.tenants
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for list := g.parked[t.TenantID]; len(list) > 0; list = g.parked[t.TenantID] {
+		p := list[0]
+		g.parked[t.TenantID] = list[1:]
+		if p.stop() {
+			return p, true // slot handed over, active count unchanged
+		}
+		// Its context already ended; the cancellation hook completes it.
+	}
+	delete(g.parked, t.TenantID)
+	if g.active[t.TenantID]--; g.active[t.TenantID] <= 0 {
+		delete(g.active, t.TenantID)
+	}
+	return nil, false
+}
+
+// unpark removes p if it is still waiting.
+func (g *tenantGate) unpark(p *parkedTask) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	list := g.parked[p.t.TenantID]
+	for i, q := range list {
+		if q == p {
+			g.parked[p.t.TenantID] = append(list[:i:i], list[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package orchestrator
+
+// Payload tracing: before/after snapshots of Task.Payload around executions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// DefaultPayloadTraceBytes bounds each snapshot when PayloadTrace.MaxBytes is zero.
+const DefaultPayloadTraceBytes = 4096
+
+// truncatedMark ends a snapshot that was cut at the size bound.
+const truncatedMark = "...(truncated)"
+
+// PayloadTrace enables payload snapshots on EventFinished events.
+type PayloadTrace struct {
+	MaxBytes int // per snapshot; zero means DefaultPayloadTraceBytes
+}
+
+// snapshotPayload renders p as JSON after passing it through the result
+// transformers, so fields they redact from outputs are redacted here too. A
+// transformer that rejects the payload hides it entirely.
+func (o *Orchestrator) snapshotPayload(ctx context.Context, taskID string, p map[string]any) string {
+	res := tasks.Result{TaskID: taskID, Output: cloneMap(p)}
+	for _, tf := range o.Transformers {
+		if err := tf(ctx, &res); err != nil {
+			return RedactedValue
+		}
+	}
+	b, err := json.Marshal(res.Output)
+	if err != nil {
+		return "!" + err.Error()
+	}
+	limit := o.TracePayloads.MaxBytes
+	if limit <= 0 {
+		limit = DefaultPayloadTraceBytes
+	}
+	if len(b) > limit {
+		return string(b[:limit]) + truncatedMark
+	}
+	return string(b)
+}
@@ -0,0 +1,80 @@
+package orchestrator
+
+// Result post-processing: transformers applied before ack/store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+// ResultTransformer inspects or rewrites a result after the agent returns and
+// before it is acked and stored. Returning an error rejects the result: the
+// task fails with the error as its reason.
+type ResultTransformer func(ctx context.Context, res *tasks.Result) error
+
+// RedactedValue replaces redacted output fields.
+const RedactedValue = "[REDACTED]"
+
+// Redact returns a transformer that masks the given Output fields. A path may
+// use dots to reach into nested maps ("learner.email"). Missing fields are
+// ignored.
+func Redact(paths ...string) ResultTransformer {
+	split := make([][]string, len(paths))
+	for i, p := range paths {
+		split[i] = strings.Split(p, ".")
+	}
+	return func(ctx context.Context, res *tasks.Result) error {
+		if res.Output == nil {
+			return nil
+		}
+		res.Output = cloneMap(res.Output)
+		for _, path := range split {
+			redactPath(res.Output, path)
+		}
+		return nil
+	}
+}
+
+func redactPath(m map[string]any, path []string) {
+	v, ok := m[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		m[path[0]] = RedactedValue
+		return
+	}
+	if child, ok := v.(map[string]any); ok {
+		child = cloneMap(child)
+		m[path[0]] = child
+		redactPath(child, path[1:])
+	}
+}
+
+// cloneMap copies the top level of m so transformers never mutate the map the
+// agent still holds.
+func cloneMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// transform runs Transformers in order, stopping at the first error.
+func (o *Orchestrator) transform(ctx context.Context, res tasks.Result) tasks.Result {
+	for i, tf := range o.Transformers {
+		if err := tf(ctx, &res); err != nil {
+			return tasks.Result{
+				TaskID: res.TaskID,
+				Status: agents.StatusFailed,
+				Err:    fmt.Errorf("result transformer %d rejected result: %w", i, err),
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,103 @@
+package orchestrator
+
+// Post-execution result invariants
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ngx-workshop/mcp-server/internal/agents"
+	"github.com/ngx-workshop/mcp-server/internal/tasks"
+)
+
+var ErrContractViolation = errors.New("agent result violates contract")
+
+// checkResult asserts what downstream code relies on:
+//   - the result's TaskID is the task's ID (an empty TaskID is tolerated and
+//     treated as the task's);
+//   - the status is ok, failed or partial (empty means ok);
+//   - ok results carry every field RequiredOutputs lists for the task type
+//     and match its OutputSchemas entry, if any.
+func (o *Orchestrator) checkResult(t tasks.Task, r agents.Result) error {
+	if r.TaskID != "" && r.TaskID != t.ID {
+		return fmt.Errorf("%w: result for task %q returned for task %q", ErrContractViolation, r.TaskID, t.ID)
+	}
+	switch r.Status {
+	case "", agents.StatusOK:
+	case agents.StatusFailed, agents.StatusPartial:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrContractViolation, r.Status)
+	}
+	for _, field := range o.RequiredOutputs[t.Type] {
+		if _, ok := r.Output[field]; !ok {
+			return fmt.Errorf("%w: %s result missing output field %q", ErrContractViolation, t.Type, field)
+		}
+	}
+	return o.checkSchema(t.Type, r.Output)
+}
+
+var ErrResultTooLarge = errors.New("agent result exceeds size limits")
+
+// ResultLimits bound what an agent may return. Zero fields are unlimited.
+type ResultLimits struct {
+	MaxBytes  int // JSON-encoded size of Output
+	MaxFields int // keys in Output, nested maps included
+}
+
+// resultLimits returns the per-type limits if set, else the global ones.
+func (o *Orchestrator) resultLimits(taskType string) ResultLimits {
+	if l, ok := o.TypeResultLimits[taskType]; ok {
+		return l
+	}
+	return o.ResultLimits
+}
+
+// checkSize enforces the result limits for t's type. MaxBytes is checked
+// against the marshaled Output, so an output that cannot be encoded at all
+// (NaN, channels, cycles) is rejected as a contract violation too.
+func (o *Orchestrator) checkSize(t tasks.Task, r agents.Result) error {
+	l := o.resultLimits(t.Type)
+	if l.MaxFields > 0 {
+		if n := countFields(r.Output, l.MaxFields); n > l.MaxFields {
+			return fmt.Errorf("%w: %w: %s output has more than %d fields", ErrContractViolation, ErrResultTooLarge, t.Type, l.MaxFields)
+		}
+	}
+	if l.MaxBytes > 0 {
+		b, err := json.Marshal(r.Output)
+		if err != nil {
+			return fmt.Errorf("%w: %s output is not JSON-encodable: %w", ErrContractViolation, t.Type, err)
+		}
+		if len(b) > l.MaxBytes {
+			return fmt.Errorf("%w: %w: %s output exceeds %d bytes", ErrContractViolation, ErrResultTooLarge, t.Type, l.MaxBytes)
+		}
+	}
+	return nil
+}
+
+// countFields counts map keys at every depth, stopping once past limit.
+func countFields(v any, limit int) int {
+	n := 0
+	var walk func(v any)
+	walk = func(v any) {
+		switch x := v.(type) {
+		case map[string]any:
+			for _, e := range x {
+				if n++; n > limit {
+					return
+				}
+				walk(e)
+			}
+		case []any:
+			for _, e := range x {
+				if n > limit {
+					return
+				}
+				walk(e)
+			}
+		}
+	}
+	walk(v)
+	return n
+}
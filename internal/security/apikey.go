@@ -0,0 +1,126 @@
+package security
+
+// Structured API keys: mcp_<env>_<base62 random><base62 checksum>
+// The checksum lets clients and servers reject mistyped or made-up keys
+// without a store lookup. It is not a signature: anyone can compute it.
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// KeyPrefix starts every structured key.
+const KeyPrefix = "mcp"
+
+const (
+	keyRandomLen   = 30 // base62 digits, about 178 bits
+	keyChecksumLen = 6  // base62 CRC32 of everything before it
+	maxKeyEnvLen   = 16
+	base62Digits   = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+var (
+	ErrMalformedKey  = errors.New("malformed API key")
+	ErrBadChecksum   = errors.New("API key checksum mismatch")
+	ErrInvalidKeyEnv = errors.New("invalid API key environment")
+)
+
+// KeyMeta is what a structured key says about itself.
+type KeyMeta struct {
+	Env    string // e.g. "live" or "test"
+	Prefix string // KeyPrefix, the environment and separators: "mcp_live_"
+}
+
+// GenerateKey returns a new random key for env, which must be 1-16
+// lowercase letters or digits.
+func GenerateKey(env string) (string, error) {
+	if !validKeyEnv(env) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKeyEnv, env)
+	}
+	var b strings.Builder
+	b.WriteString(keyPrefix(env))
+	limit := big.NewInt(int64(len(base62Digits)))
+	for i := 0; i < keyRandomLen; i++ {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(base62Digits[n.Int64()])
+	}
+	b.WriteString(keyChecksum(b.String()))
+	return b.String(), nil
+}
+
+// ParseKey checks raw's format and checksum and returns its metadata. It
+// says nothing about whether the key was ever issued or is still valid.
+func ParseKey(raw string) (KeyMeta, error) {
+	rest, ok := strings.CutPrefix(raw, KeyPrefix+"_")
+	if !ok {
+		return KeyMeta{}, ErrMalformedKey
+	}
+	env, body, ok := strings.Cut(rest, "_")
+	if !ok || !validKeyEnv(env) || len(body) != keyRandomLen+keyChecksumLen {
+		return KeyMeta{}, ErrMalformedKey
+	}
+	for i := 0; i < len(body); i++ {
+		if strings.IndexByte(base62Digits, body[i]) < 0 {
+			return KeyMeta{}, ErrMalformedKey
+		}
+	}
+	split := len(raw) - keyChecksumLen
+	if raw[split:] != keyChecksum(raw[:split]) {
+		return KeyMeta{}, ErrBadChecksum
+	}
+	return KeyMeta{Env: env, Prefix: keyPrefix(env)}, nil
+}
+
+// RequireKeyFormat rejects requests whose API key (see APIKeys) is not a
+// well-formed structured key for one of envs (any environment if none are
+// given) before next is consulted, so garbage keys never reach a key store.
+func RequireKeyFormat(next Authenticator, envs ...string) Authenticator {
+	return keyFormatCheck{next: next, envs: envs}
+}
+
+type keyFormatCheck struct {
+	next Authenticator
+	envs []string
+}
+
+func (k keyFormatCheck) Authenticate(r *http.Request) (*Claims, error) {
+	meta, err := ParseKey(requestKey(r))
+	if err != nil || len(k.envs) > 0 && !slices.Contains(k.envs, meta.Env) {
+		return nil, ErrUnauthenticated
+	}
+	return k.next.Authenticate(r)
+}
+
+func keyPrefix(env string) string { return KeyPrefix + "_" + env + "_" }
+
+func validKeyEnv(env string) bool {
+	if env == "" || len(env) > maxKeyEnvLen {
+		return false
+	}
+	for _, c := range env {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// keyChecksum is the CRC32 of s in keyChecksumLen base62 digits.
+func keyChecksum(s string) string {
+	n := crc32.ChecksumIEEE([]byte(s))
+	out := make([]byte, keyChecksumLen)
+	for i := keyChecksumLen - 1; i >= 0; i-- {
+		out[i] = base62Digits[n%62]
+		n /= 62
+	}
+	return string(out)
+}
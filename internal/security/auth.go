@@ -3,3 +3,95 @@ package security
 // API keys/JWT for microfrontends & agents
 // This file implements authentication mechanisms including API key validation
 // and JWT token handling for securing microfrontend and agent communications
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	ErrForbidden       = errors.New("principal lacks required role")
+)
+
+// Roles the platform knows about. Principals may carry others.
+const (
+	RoleLearner    = "learner"
+	RoleInstructor = "instructor"
+	RoleAdmin      = "admin"
+)
+
+// Claims describes an authenticated principal.
+type Claims struct {
+	Subject  string
+	TenantID string
+	Roles    []string
+}
+
+// HasRole reports whether the principal holds role.
+func (c *Claims) HasRole(role string) bool {
+	if c == nil {
+		return false
+	}
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator resolves the principal behind a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Claims, error)
+}
+
+// APIKeys authenticates requests carrying a static key in the X-API-Key header
+// or as "Authorization: Bearer <key>". Keys map to the claims they grant.
+type APIKeys map[string]Claims
+
+func (k APIKeys) Authenticate(r *http.Request) (*Claims, error) {
+	key := requestKey(r)
+	if key == "" {
+		return nil, ErrUnauthenticated
+	}
+	for known, c := range k {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(key)) == 1 {
+			c := c
+			return &c, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
+
+// requestKey returns the API key r carries, or "".
+func requestKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return bearer(r)
+}
+
+func bearer(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+type claimsKey struct{}
+
+// WithClaims returns a context carrying the authenticated principal.
+func WithClaims(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, c)
+}
+
+// ClaimsFromContext returns the principal stored by the auth middleware.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(*Claims)
+	return c, ok && c != nil
+}
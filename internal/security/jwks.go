@@ -0,0 +1,199 @@
+package security
+
+// JWKS key sets: fetch and cache an identity provider's signing keys
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("no signing key with that key ID")
+
+// DefaultJWKSRefresh is the minimum time between two fetches of a key set.
+const DefaultJWKSRefresh = 30 * time.Second
+
+// DefaultJWKSTimeout bounds a single fetch of a key set.
+const DefaultJWKSTimeout = 10 * time.Second
+
+// maxJWKSBytes caps how much of a key set response is read.
+const maxJWKSBytes = 1 << 20
+
+// JWKS caches the public keys published at URL, by key ID. A key ID missing
+// from the cache (a new key after rotation, or one that was retired) causes
+// at most one fetch, and fetches happen at most once per MinRefresh no
+// matter how many requests miss at the same time.
+type JWKS struct {
+	URL        string
+	Client     *http.Client  // defaults to http.DefaultClient
+	MinRefresh time.Duration // defaults to DefaultJWKSRefresh
+	Timeout    time.Duration // per fetch; defaults to DefaultJWKSTimeout
+
+	fetchMu sync.Mutex // serializes refreshes
+	mu      sync.RWMutex
+	keys    map[string]any // kid -> *rsa.PublicKey or *ecdsa.PublicKey
+	fetched time.Time
+	now     func() time.Time
+}
+
+// NewJWKS returns a key set cache for url. Keys are fetched on first use.
+func NewJWKS(url string) *JWKS {
+	return &JWKS{URL: url}
+}
+
+// WithJWKS makes the validator accept RS256 and ES256 tokens signed by a key
+// from ks, selected by the token's kid header.
+func WithJWKS(ks *JWKS) ValidateOption {
+	return func(v *JWTValidator) { v.jwks = ks }
+}
+
+// Key returns the public key for kid, refreshing the set once if kid is not
+// cached and the last fetch is at least MinRefresh old.
+func (ks *JWKS) Key(ctx context.Context, kid string) (any, error) {
+	if k, ok := ks.cached(kid); ok {
+		return k, nil
+	}
+	ks.fetchMu.Lock()
+	defer ks.fetchMu.Unlock()
+	// Whoever held fetchMu before us may have fetched the key already.
+	if k, ok := ks.cached(kid); ok {
+		return k, nil
+	}
+	if !ks.refreshDue() {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	if err := ks.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if k, ok := ks.cached(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+}
+
+func (ks *JWKS) cached(kid string) (any, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+func (ks *JWKS) clock() time.Time {
+	if ks.now != nil {
+		return ks.now()
+	}
+	return time.Now()
+}
+
+func (ks *JWKS) refreshDue() bool {
+	every := ks.MinRefresh
+	if every <= 0 {
+		every = DefaultJWKSRefresh
+	}
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.fetched.IsZero() || ks.clock().Sub(ks.fetched) >= every
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// refresh replaces the cached keys with the ones currently published. A
+// failed fetch keeps the old keys but still counts against MinRefresh. The
+// fetch is detached from ctx and bounded by Timeout instead, so one caller
+// giving up cannot waste the refresh for everyone else waiting on the key.
+func (ks *JWKS) refresh(ctx context.Context) error {
+	ks.mu.Lock()
+	ks.fetched = ks.clock()
+	ks.mu.Unlock()
+
+	timeout := ks.Timeout
+	if timeout <= 0 {
+		timeout = DefaultJWKSTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.URL, nil)
+	if err != nil {
+		return err
+	}
+	client := ks.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch JWKS: %s", resp.Status)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&set); err != nil {
+		return fmt.Errorf("decode JWKS: %w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.publicKey(); err == nil {
+			keys[k.Kid] = pub
+		}
+	}
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	dec := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := dec(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := dec(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := dec(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
@@ -0,0 +1,230 @@
+package security
+
+// JWT bearer token validation (HS256, or RS256/ES256 via JWKS)
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+)
+
+// DefaultLeeway is the clock skew tolerated on exp and nbf unless WithLeeway
+// says otherwise.
+const DefaultLeeway = 5 * time.Second
+
+// JWTValidator verifies bearer tokens and maps their claims: sub to Subject,
+// tenant_id to TenantID and roles to Roles.
+type JWTValidator struct {
+	secret   []byte
+	jwks     *JWKS
+	leeway   time.Duration
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// ValidateOption configures a JWTValidator.
+type ValidateOption func(*JWTValidator)
+
+// WithLeeway tolerates clock skew of d between the token issuer and this
+// service: a token counts as expired only d after exp and as valid from d
+// before nbf.
+func WithLeeway(d time.Duration) ValidateOption {
+	return func(v *JWTValidator) { v.leeway = d }
+}
+
+// WithIssuer requires the iss claim to equal iss.
+func WithIssuer(iss string) ValidateOption {
+	return func(v *JWTValidator) { v.issuer = iss }
+}
+
+// WithAudience requires aud to be, or contain, aud.
+func WithAudience(aud string) ValidateOption {
+	return func(v *JWTValidator) { v.audience = aud }
+}
+
+// WithClock replaces time.Now, e.g. for tests.
+func WithClock(now func() time.Time) ValidateOption {
+	return func(v *JWTValidator) { v.now = now }
+}
+
+// NewJWTValidator validates HS256 tokens signed with secret. With WithJWKS
+// it validates tokens signed by the key set as well; secret may then be nil
+// to accept only those.
+func NewJWTValidator(secret []byte, opts ...ValidateOption) *JWTValidator {
+	v := &JWTValidator{secret: secret, leeway: DefaultLeeway, now: time.Now}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Authenticate validates the request's bearer token.
+func (v *JWTValidator) Authenticate(r *http.Request) (*Claims, error) {
+	tok := bearer(r)
+	if tok == "" {
+		return nil, ErrUnauthenticated
+	}
+	c, err := v.ValidateContext(r.Context(), tok)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+	return c, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+	TenantID  string          `json:"tenant_id"`
+	Roles     []string        `json:"roles"`
+}
+
+// Validate checks token's signature and time and audience claims and
+// returns the principal it carries.
+func (v *JWTValidator) Validate(token string) (*Claims, error) {
+	return v.ValidateContext(context.Background(), token)
+}
+
+// ValidateContext is Validate with a context bounding any JWKS fetch.
+func (v *JWTValidator) ValidateContext(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: want 3 segments, got %d", ErrInvalidToken, len(parts))
+	}
+	var h jwtHeader
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+	if err := v.verify(ctx, h, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+	var c jwtClaims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, err
+	}
+	if err := v.checkClaims(c); err != nil {
+		return nil, err
+	}
+	return &Claims{Subject: c.Subject, TenantID: c.TenantID, Roles: c.Roles}, nil
+}
+
+// verify checks sig over signed. The key must match the algorithm, so a
+// JWKS public key can never be used as an HMAC secret.
+func (v *JWTValidator) verify(ctx context.Context, h jwtHeader, signed string, sig []byte) error {
+	bad := fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	switch h.Alg {
+	case "HS256":
+		if v.secret == nil {
+			break
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return bad
+		}
+		return nil
+	case "RS256", "ES256":
+		if v.jwks == nil {
+			break
+		}
+		key, err := v.jwks.Key(ctx, h.Kid)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+		}
+		digest := sha256.Sum256([]byte(signed))
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			if h.Alg != "RS256" || rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) != nil {
+				return bad
+			}
+			return nil
+		case *ecdsa.PublicKey:
+			// JWS ES256 signatures are r || s, 32 bytes each.
+			if h.Alg != "ES256" || len(sig) != 64 {
+				return bad
+			}
+			r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+			if !ecdsa.Verify(k, digest[:], r, s) {
+				return bad
+			}
+			return nil
+		}
+		return bad
+	}
+	return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, h.Alg)
+}
+
+func (v *JWTValidator) checkClaims(c jwtClaims) error {
+	now := v.now()
+	if c.ExpiresAt != nil && !now.Before(time.Unix(*c.ExpiresAt, 0).Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != nil && now.Before(time.Unix(*c.NotBefore, 0).Add(-v.leeway)) {
+		return ErrTokenNotYetValid
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return fmt.Errorf("%w: issuer %q", ErrInvalidToken, c.Issuer)
+	}
+	if v.audience != "" && !hasAudience(c.Audience, v.audience) {
+		return fmt.Errorf("%w: audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+// hasAudience accepts aud as a single string or an array of them.
+func hasAudience(raw json.RawMessage, want string) bool {
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return one == want
+	}
+	var many []string
+	if json.Unmarshal(raw, &many) != nil {
+		return false
+	}
+	for _, a := range many {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return nil
+}
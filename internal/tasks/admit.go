@@ -0,0 +1,133 @@
+package tasks
+
+// Admission control: external policy approval at enqueue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var ErrDenied = errors.New("task denied by admission policy")
+
+// DeniedError carries the admitter's reason for rejecting a task.
+type DeniedError struct {
+	TaskID string
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	if e.Reason == "" {
+		return ErrDenied.Error() + ": " + e.TaskID
+	}
+	return ErrDenied.Error() + ": " + e.TaskID + ": " + e.Reason
+}
+
+func (e *DeniedError) Is(target error) bool { return target == ErrDenied }
+
+// Admitter approves or denies tasks before they are accepted. A non-nil error
+// means no verdict could be reached; the task is rejected with that error.
+type Admitter interface {
+	Admit(ctx context.Context, t Task) (allowed bool, reason string, err error)
+}
+
+// AllowAll admits every task; it is what a queue without an admitter does.
+type AllowAll struct{}
+
+func (AllowAll) Admit(context.Context, Task) (bool, string, error) { return true, "", nil }
+
+// WithAdmitter consults a before every Enqueue. It is called without the
+// queue lock held, so slow admitters only delay their own caller.
+func WithAdmitter(a Admitter) QueueOption {
+	return func(q *MemoryQueue) { q.admitter = a }
+}
+
+// admit runs the configured admitter, if any.
+func (q *MemoryQueue) admit(ctx context.Context, t Task) error {
+	if q.admitter == nil {
+		return nil
+	}
+	ok, reason, err := q.admitter.Admit(ctx, t)
+	if err != nil {
+		return fmt.Errorf("admission: %w", err)
+	}
+	if !ok {
+		return &DeniedError{TaskID: t.ID, Reason: reason}
+	}
+	return nil
+}
+
+// HTTPAdmitter POSTs each task as JSON to URL and expects a 2xx response of
+// the form {"allow": true|false, "reason": "..."}.
+//
+// When the webhook cannot be reached, times out or answers with anything else,
+// FailOpen decides: true admits the task anyway, false (the default) rejects it.
+type HTTPAdmitter struct {
+	URL      string
+	Client   *http.Client  // defaults to http.DefaultClient
+	Timeout  time.Duration // per call; defaults to 2s
+	FailOpen bool
+}
+
+type admitRequest struct {
+	ID       string         `json:"id"`
+	Type     string         `json:"type"`
+	Payload  map[string]any `json:"payload,omitempty"`
+	DedupKey string         `json:"dedupKey,omitempty"`
+}
+
+type admitResponse struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason"`
+}
+
+func (h *HTTPAdmitter) Admit(ctx context.Context, t Task) (bool, string, error) {
+	allowed, reason, err := h.call(ctx, t)
+	if err != nil && h.FailOpen {
+		return true, "admitter unavailable, failing open: " + err.Error(), nil
+	}
+	return allowed, reason, err
+}
+
+func (h *HTTPAdmitter) call(ctx context.Context, t Task) (bool, string, error) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(admitRequest{ID: t.ID, Type: t.Type, Payload: t.Payload, DedupKey: t.DedupKey})
+	if err != nil {
+		return false, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return false, "", fmt.Errorf("admission webhook returned %s", resp.Status)
+	}
+	var out admitResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&out); err != nil {
+		return false, "", fmt.Errorf("decode admission response: %w", err)
+	}
+	return out.Allow, out.Reason, nil
+}
@@ -0,0 +1,60 @@
+package tasks
+
+// Blob store (in-memory + adapter interface)
+// Holds large payload fields outside the queue; tasks carry references.
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps opaque byte blobs addressed by the reference Put returns.
+// Their users delete blobs once no task refers to them any more; stores
+// backed by durable storage may add an expiry of their own (e.g. a bucket
+// lifecycle rule) to catch blobs orphaned by a crash.
+type BlobStore interface {
+	Put(ctx context.Context, data []byte) (ref string, err error)
+	Get(ctx context.Context, ref string) ([]byte, error)
+	Delete(ctx context.Context, ref string) error
+}
+
+// MemoryBlobStore is a threadsafe in-memory BlobStore, for tests and
+// single-process deployments.
+type MemoryBlobStore struct {
+	IDs IDGenerator // defaults to UUIDv4
+
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{IDs: UUIDv4{}, blobs: make(map[string][]byte)}
+}
+
+func (s *MemoryBlobStore) Put(ctx context.Context, data []byte) (string, error) {
+	ref := s.IDs.NewID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[ref] = append([]byte(nil), data...)
+	return ref, nil
+}
+
+func (s *MemoryBlobStore) Get(ctx context.Context, ref string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.blobs[ref]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return b, nil
+}
+
+func (s *MemoryBlobStore) Delete(ctx context.Context, ref string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, ref)
+	return nil
+}
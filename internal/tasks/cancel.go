@@ -0,0 +1,51 @@
+package tasks
+
+// Structured cancellation reasons
+
+import "fmt"
+
+// CancelReason says why a task was cut short or withdrawn, so records of
+// cancellations can be told apart after an incident.
+type CancelReason int
+
+const (
+	CancelNone      CancelReason = iota // not cancelled
+	CancelOperator                      // someone stopped the run or task on purpose
+	CancelFailFast                      // a related task failed and this one was no longer needed
+	CancelTimeout                       // a run or execution deadline passed
+	CancelShutdown                      // the process is stopping
+	CancelPreempted                     // evicted by a higher-priority task, see WithMaxPending
+)
+
+func (r CancelReason) String() string {
+	switch r {
+	case CancelNone:
+		return "none"
+	case CancelOperator:
+		return "operator"
+	case CancelFailFast:
+		return "fail-fast"
+	case CancelTimeout:
+		return "timeout"
+	case CancelShutdown:
+		return "shutdown"
+	case CancelPreempted:
+		return "preempted"
+	}
+	return fmt.Sprintf("CancelReason(%d)", int(r))
+}
+
+// State is the terminal state of a task cancelled for r: StateExpired for
+// timeouts, StateCancelled otherwise.
+func (r CancelReason) State() TaskState {
+	if r == CancelTimeout {
+		return StateExpired
+	}
+	return StateCancelled
+}
+
+// DropReason is Drop recording why the task was withdrawn; the state is
+// reason.State().
+func (q *MemoryQueue) DropReason(taskID string, reason CancelReason) bool {
+	return q.drop(taskID, reason.State(), reason)
+}
@@ -0,0 +1,112 @@
+package tasks
+
+// Compressed result store
+// Large outputs are kept gzip-compressed and inflated transparently on Get.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"sync"
+)
+
+// DefaultCompressThreshold is the encoded output size, in bytes, below which
+// CompressedResultStore keeps results as-is: gzip costs more than it saves there.
+const DefaultCompressThreshold = 1024
+
+func init() {
+	// Output values are usually nested JSON-like data; gob needs the
+	// container types registered to carry them inside interfaces.
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+	gob.Register([]map[string]any{})
+}
+
+// CompressedResultStore is a threadsafe in-memory ResultStore that gzips the
+// Output of large results. Outputs are encoded with gob, so Get returns the
+// same Go types that were Put (an int stays an int). Outputs gob cannot encode
+// (unregistered concrete types) are stored uncompressed.
+type CompressedResultStore struct {
+	// Threshold is the encoded size at which outputs get compressed.
+	Threshold int
+
+	mu      sync.RWMutex
+	results map[string]storedResult
+}
+
+type storedResult struct {
+	res  Result // Output is nil when blob is set
+	blob []byte // gzipped gob of the Output
+}
+
+// NewCompressedResultStore creates a store compressing outputs of at least
+// threshold bytes; zero means DefaultCompressThreshold.
+func NewCompressedResultStore(threshold int) *CompressedResultStore {
+	if threshold <= 0 {
+		threshold = DefaultCompressThreshold
+	}
+	return &CompressedResultStore{Threshold: threshold, results: make(map[string]storedResult)}
+}
+
+func (s *CompressedResultStore) Put(ctx context.Context, res Result) error {
+	sr := storedResult{res: res}
+	if blob, ok := s.compress(res.Output); ok {
+		sr.res.Output, sr.blob = nil, blob
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[res.TaskID] = sr
+	return nil
+}
+
+func (s *CompressedResultStore) Get(ctx context.Context, taskID string) (Result, bool, error) {
+	s.mu.RLock()
+	sr, ok := s.results[taskID]
+	s.mu.RUnlock()
+	if !ok {
+		return Result{}, false, nil
+	}
+	res := sr.res
+	if sr.blob != nil {
+		out, err := decompressOutput(sr.blob)
+		if err != nil {
+			return Result{}, false, err
+		}
+		res.Output = out
+	}
+	return res, true, nil
+}
+
+// compress returns the gzipped encoding of out if it is worth storing that way.
+func (s *CompressedResultStore) compress(out map[string]any) ([]byte, bool) {
+	if len(out) == 0 {
+		return nil, false
+	}
+	var raw bytes.Buffer
+	if gob.NewEncoder(&raw).Encode(out) != nil || raw.Len() < s.Threshold {
+		return nil, false
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw.Bytes()); err != nil {
+		return nil, false
+	}
+	if zw.Close() != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+func decompressOutput(blob []byte) (map[string]any, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	var out map[string]any
+	if err := gob.NewDecoder(zr).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
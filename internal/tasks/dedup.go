@@ -0,0 +1,60 @@
+package tasks
+
+// Dedup window: suppress repeated enqueues of the same logical task
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrDuplicate = errors.New("duplicate task within dedup window")
+
+// DuplicateError is returned by Enqueue when a task's DedupKey was already
+// seen inside the dedup window. OriginalID names the task that was kept.
+type DuplicateError struct {
+	Key        string
+	OriginalID string
+}
+
+func (e *DuplicateError) Error() string {
+	return ErrDuplicate.Error() + ": " + e.Key + " (original " + e.OriginalID + ")"
+}
+
+func (e *DuplicateError) Is(target error) bool { return target == ErrDuplicate }
+
+type dedupEntry struct {
+	taskID string
+	at     time.Time
+}
+
+// checkDuplicate rejects t if its key is live in the window and counts the
+// outcome. Caller must hold q.mu.
+func (q *MemoryQueue) checkDuplicate(t Task) error {
+	if q.dedupWindow <= 0 || t.DedupKey == "" {
+		return nil
+	}
+	now := q.now()
+	if now.Sub(q.lastSweep) > q.dedupWindow {
+		for k, e := range q.dedup {
+			if now.Sub(e.at) > q.dedupWindow {
+				delete(q.dedup, k)
+			}
+		}
+		q.lastSweep = now
+	}
+	c := q.counters.dedupFor(t.Type)
+	if e, ok := q.dedup[t.DedupKey]; ok && now.Sub(e.at) <= q.dedupWindow {
+		c.hits.Add(1)
+		return &DuplicateError{Key: t.DedupKey, OriginalID: e.taskID}
+	}
+	c.misses.Add(1)
+	return nil
+}
+
+// remember records t as the original for its key. Caller must hold q.mu.
+func (q *MemoryQueue) remember(t Task) {
+	if q.dedupWindow <= 0 || t.DedupKey == "" {
+		return
+	}
+	q.dedup[t.DedupKey] = dedupEntry{taskID: t.ID, at: q.now()}
+}
@@ -0,0 +1,127 @@
+package tasks
+
+// Task dependencies with priority inheritance
+// A task is held back until the tasks it depends on are acked. So that a
+// high-priority task is not stuck behind its own low-priority dependency,
+// dependencies inherit the highest priority among their dependents.
+
+// depGraph tracks the dependency edges among the tasks a queue holds.
+type depGraph struct {
+	dependsOn  map[string][]string // task ID -> its DependsOn, while held
+	dependents map[string][]string // task ID -> held tasks depending on it
+	inherited  map[string]int      // task ID -> effective priority, for tasks with edges
+}
+
+// priorityOf is t's effective priority: its own or an inherited, higher one.
+// Caller must hold q.mu.
+func (q *MemoryQueue) priorityOf(t Task) int {
+	if p, ok := q.deps.inherited[t.ID]; ok && p > t.Priority {
+		return p
+	}
+	return t.Priority
+}
+
+// trackDeps records t's edges and propagates priorities along them: t
+// inherits from already-held dependents, and t's dependencies, transitively,
+// inherit t's effective priority. Caller must hold q.mu.
+func (q *MemoryQueue) trackDeps(t Task) {
+	g := &q.deps
+	if len(t.DependsOn) == 0 && len(g.dependents[t.ID]) == 0 {
+		return
+	}
+	if g.dependsOn == nil {
+		g.dependsOn = make(map[string][]string)
+		g.dependents = make(map[string][]string)
+		g.inherited = make(map[string]int)
+	}
+	p := t.Priority
+	for _, id := range g.dependents[t.ID] {
+		p = max(p, g.inherited[id])
+	}
+	g.inherited[t.ID] = p
+	if len(t.DependsOn) > 0 {
+		g.dependsOn[t.ID] = t.DependsOn
+		for _, d := range t.DependsOn {
+			g.dependents[d] = append(g.dependents[d], t.ID)
+		}
+		q.inherit(t.DependsOn, p)
+	}
+}
+
+// inherit raises ids and their dependencies to at least p. Priorities only
+// ever rise, so dependency cycles terminate. Caller must hold q.mu.
+func (q *MemoryQueue) inherit(ids []string, p int) {
+	g := &q.deps
+	for _, id := range ids {
+		if cur, ok := g.inherited[id]; ok && cur >= p {
+			continue
+		}
+		g.inherited[id] = p
+		q.inherit(g.dependsOn[id], p)
+	}
+}
+
+// forgetDeps drops taskID's edges once the queue no longer holds it, and
+// reports whether tasks were waiting on it. Caller must hold q.mu.
+func (q *MemoryQueue) forgetDeps(taskID string) bool {
+	g := &q.deps
+	if g.dependsOn == nil {
+		return false
+	}
+	for _, d := range g.dependsOn[taskID] {
+		list := g.dependents[d]
+		for i, id := range list {
+			if id == taskID {
+				list = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(g.dependents, d)
+		} else {
+			g.dependents[d] = list
+		}
+	}
+	waited := len(g.dependents[taskID]) > 0
+	delete(g.dependsOn, taskID)
+	delete(g.dependents, taskID)
+	delete(g.inherited, taskID)
+	return waited
+}
+
+// dependencyClosure returns ids and, transitively, the tasks they depend on.
+// Caller must hold q.mu.
+func (q *MemoryQueue) dependencyClosure(ids []string) map[string]bool {
+	out := make(map[string]bool, len(ids))
+	for len(ids) > 0 {
+		id := ids[len(ids)-1]
+		ids = ids[:len(ids)-1]
+		if out[id] {
+			continue
+		}
+		out[id] = true
+		ids = append(ids, q.deps.dependsOn[id]...)
+	}
+	return out
+}
+
+// dependencyFilter returns the eligibility check that holds back tasks with
+// a dependency still pending or in flight, or nil if no task has one.
+// Caller must hold q.mu.
+func (q *MemoryQueue) dependencyFilter() func(i int) bool {
+	if len(q.deps.dependsOn) == 0 {
+		return nil
+	}
+	held := make(map[string]bool, len(q.pending))
+	for _, t := range q.pending {
+		held[t.ID] = true
+	}
+	return func(i int) bool {
+		for _, d := range q.pending[i].DependsOn {
+			if _, inflight := q.inflight[d]; inflight || held[d] {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,50 @@
+package tasks
+
+// Shutdown drain: hand every task back to the caller
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrQueueDrained = errors.New("queue has been drained")
+
+// Drain stops the queue and returns every task it still holds, in-flight
+// tasks first (they were dequeued earliest) in enqueue order, then pending in
+// queue order. The queue is left empty: later Enqueue and Dequeue calls,
+// including ones blocked right now, fail with ErrQueueDrained, and acks for
+// the returned in-flight tasks fail with ErrUnknownTask. The caller owns the
+// returned tasks and should re-enqueue them on another backend or checkpoint
+// them.
+//
+// Drained tasks are removed from the WAL and the state store, so a restart of
+// this replica does not deliver them a second time.
+func (q *MemoryQueue) Drain(ctx context.Context) ([]Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.drained {
+		return nil, ErrQueueDrained
+	}
+
+	out := append(q.inflightTasks(), q.pending...)
+
+	q.pending = nil
+	q.inflight = make(map[string]Task)
+	q.busy = nil
+	q.deps = depGraph{}
+	clear(q.deliveries)
+	clear(q.stamps)
+	clear(q.seq)
+	q.drained = true
+	q.signal()
+
+	for _, t := range out {
+		if q.states != nil {
+			q.states.Forget(t.ID)
+		}
+	}
+	return out, q.compactLocked()
+}
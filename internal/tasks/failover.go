@@ -0,0 +1,289 @@
+package tasks
+
+// Queue backend failover
+// Routes to a local fallback while the primary backend is unreachable and
+// moves the buffered tasks back once it recovers.
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultProbeInterval is how often a failed-over queue probes its primary
+// when FailoverQueue.ProbeInterval is zero.
+const DefaultProbeInterval = 5 * time.Second
+
+// ForwardedStatus is the Status the fallback acks tasks with once they have
+// been handed back to the primary.
+const ForwardedStatus = "forwarded"
+
+// FailoverState is a snapshot of a FailoverQueue for dashboards and alerts.
+type FailoverState struct {
+	PrimaryUp bool
+	Since     time.Time // last transition between primary and fallback
+	Failovers int       // times the primary was marked down
+	Forwarded int       // tasks moved from the fallback back to the primary
+}
+
+// FailoverQueue sends traffic to Primary and switches to Fallback when the
+// primary fails with a backend error. While failed over, Run probes the
+// primary and, once it answers, drains the fallback back into it. Acks are
+// routed to whichever queue handed the task out.
+type FailoverQueue struct {
+	Primary  Queue
+	Fallback Queue
+
+	// Probe checks whether the primary is reachable again. Defaults to the
+	// primary's Ping method if it has one; otherwise the primary is simply
+	// tried again after every ProbeInterval.
+	Probe         func(ctx context.Context) error
+	ProbeInterval time.Duration
+
+	// IsBackendError tells outages from rejections of the task itself.
+	// Defaults to IsBackendError.
+	IsBackendError func(error) bool
+
+	mu       sync.Mutex
+	down     bool
+	state    FailoverState
+	owner    map[string]Queue // task ID -> queue that handed it out, for Ack
+	stranded []Task           // taken from the fallback but not yet accepted by the primary
+	changed  chan struct{}    // closed and replaced on every up/down transition
+}
+
+// NewFailoverQueue creates a failover queue that starts on the primary.
+func NewFailoverQueue(primary, fallback Queue) *FailoverQueue {
+	return &FailoverQueue{Primary: primary, Fallback: fallback}
+}
+
+// IsBackendError reports whether err looks like an outage rather than the
+// queue refusing a particular task (invalid, duplicate, too large, denied,
+// full) or shutting down.
+func IsBackendError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, target := range []error{
+		ErrEmptyTaskID, ErrUnknownTask, ErrDuplicate, ErrPayloadTooLarge,
+		ErrDenied, ErrQueueDrained, ErrQueueClosed, ErrQueueFull, ErrInvalidTransition,
+		context.Canceled, context.DeadlineExceeded,
+	} {
+		if errors.Is(err, target) {
+			return false
+		}
+	}
+	return true
+}
+
+// Enqueue writes to the primary, or to the fallback while failed over. A
+// backend error from the primary fails over and retries on the fallback.
+func (f *FailoverQueue) Enqueue(ctx context.Context, t Task) error {
+	if !f.isDown() {
+		err := f.Primary.Enqueue(ctx, t)
+		if !f.backendError(err) {
+			return err
+		}
+		f.markDown()
+	}
+	return f.Fallback.Enqueue(ctx, t)
+}
+
+// Dequeue hands out tasks from the primary, or from the fallback while failed
+// over. It switches over as soon as the state changes, even while blocked.
+func (f *FailoverQueue) Dequeue(ctx context.Context) (Task, error) {
+	for {
+		down, changed := f.current()
+		q := f.Primary
+		if down {
+			q = f.Fallback
+		}
+		qctx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-changed:
+				cancel()
+			case <-qctx.Done():
+			}
+		}()
+		t, err := q.Dequeue(qctx)
+		cancel()
+		switch {
+		case err == nil:
+			f.track(t.ID, q)
+			return t, nil
+		case ctx.Err() != nil:
+			return Task{}, ctx.Err()
+		case qctx.Err() != nil:
+			continue // state changed while blocked
+		case !down && f.backendError(err):
+			f.markDown()
+			continue
+		}
+		return Task{}, err
+	}
+}
+
+// Ack resolves the task on the queue it came from.
+func (f *FailoverQueue) Ack(ctx context.Context, taskID string, res Result) error {
+	f.mu.Lock()
+	q, ok := f.owner[taskID]
+	delete(f.owner, taskID)
+	f.mu.Unlock()
+	if !ok {
+		q = f.Primary
+	}
+	return q.Ack(ctx, taskID, res)
+}
+
+// State returns the current failover state.
+func (f *FailoverQueue) State() FailoverState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.state
+	s.PrimaryUp = !f.down
+	return s
+}
+
+// Run probes the primary every ProbeInterval while failed over and, once it
+// is reachable, switches back and forwards the fallback's tasks to it. It
+// returns when ctx is done.
+func (f *FailoverQueue) Run(ctx context.Context) error {
+	interval := f.ProbeInterval
+	if interval <= 0 {
+		interval = DefaultProbeInterval
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+		if !f.isDown() || f.probe(ctx) != nil {
+			continue
+		}
+		f.markUp()
+		if err := f.forward(ctx); f.backendError(err) {
+			f.markDown()
+		}
+	}
+}
+
+func (f *FailoverQueue) probe(ctx context.Context) error {
+	if f.Probe != nil {
+		return f.Probe(ctx)
+	}
+	if p, ok := f.Primary.(interface{ Ping(context.Context) error }); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// forward moves every task pending in the fallback to the primary.
+func (f *FailoverQueue) forward(ctx context.Context) error {
+	for {
+		f.mu.Lock()
+		var t Task
+		var fromStranded bool
+		if n := len(f.stranded); n > 0 {
+			t, fromStranded = f.stranded[0], true
+		}
+		f.mu.Unlock()
+
+		if !fromStranded {
+			// An already-done context makes Dequeue return only what is
+			// pending right now instead of waiting for more.
+			done, cancel := context.WithCancel(ctx)
+			cancel()
+			var err error
+			if t, err = f.Fallback.Dequeue(done); err != nil {
+				return nil
+			}
+			if err := f.Fallback.Ack(ctx, t.ID, Result{TaskID: t.ID, Status: ForwardedStatus}); err != nil {
+				return err
+			}
+		}
+		if err := f.Primary.Enqueue(ctx, t); err != nil {
+			if f.backendError(err) {
+				if !fromStranded {
+					f.mu.Lock()
+					f.stranded = append(f.stranded, t)
+					f.mu.Unlock()
+				}
+				return err
+			}
+			// The primary rejects the task itself; retrying will not help.
+		}
+		f.mu.Lock()
+		if fromStranded {
+			f.stranded = f.stranded[1:]
+		}
+		f.state.Forwarded++
+		f.mu.Unlock()
+	}
+}
+
+func (f *FailoverQueue) backendError(err error) bool {
+	if f.IsBackendError != nil {
+		return f.IsBackendError(err)
+	}
+	return IsBackendError(err)
+}
+
+func (f *FailoverQueue) track(taskID string, q Queue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.owner == nil {
+		f.owner = make(map[string]Queue)
+	}
+	f.owner[taskID] = q
+}
+
+func (f *FailoverQueue) isDown() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.down
+}
+
+// current returns the state and a channel closed on its next change.
+func (f *FailoverQueue) current() (bool, <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.changed == nil {
+		f.changed = make(chan struct{})
+	}
+	return f.down, f.changed
+}
+
+func (f *FailoverQueue) markDown() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return
+	}
+	f.down = true
+	f.state.Failovers++
+	f.transitioned()
+}
+
+func (f *FailoverQueue) markUp() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.down {
+		return
+	}
+	f.down = false
+	f.transitioned()
+}
+
+// transitioned records the switch and wakes blocked dequeuers. Caller must hold f.mu.
+func (f *FailoverQueue) transitioned() {
+	f.state.Since = time.Now()
+	if f.changed != nil {
+		close(f.changed)
+	}
+	f.changed = make(chan struct{})
+}
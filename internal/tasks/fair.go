@@ -0,0 +1,72 @@
+package tasks
+
+// Weighted fair dequeueing across task types
+
+// DefaultTypeWeight applies to task types missing from the weights map.
+const DefaultTypeWeight = 1
+
+// WithTypeWeights switches the queue to weighted-fair dequeueing. Each task
+// type with pending work receives dequeues in proportion to its weight, so a
+// type weighted 3 against a bulk type weighted 1 gets at least 75% of the
+// dequeues while both have backlog. Share a type does not use (its backlog is
+// empty) spills over to the types that do. Within a type, the highest
+// (effective) Priority goes first and order is otherwise FIFO.
+//
+// Selection uses smooth weighted round-robin, which interleaves types instead
+// of serving them in bursts.
+func WithTypeWeights(weights map[string]int) QueueOption {
+	return func(q *MemoryQueue) {
+		q.weights = make(map[string]int, len(weights))
+		for t, w := range weights {
+			if w > 0 {
+				q.weights[t] = w
+			}
+		}
+		q.credit = make(map[string]int)
+	}
+}
+
+// nextFair picks the type to serve and returns the index of its next eligible
+// pending task, or -1. eligible, if non-nil, is called once per index
+// in ascending order. Caller must hold q.mu.
+func (q *MemoryQueue) nextFair(eligible func(i int) bool) int {
+	first := make(map[string]int) // type -> index of its next pending task
+	order := make([]string, 0)    // types in order of first appearance, for stable ties
+	for i, t := range q.pending {
+		if eligible != nil && !eligible(i) {
+			continue
+		}
+		j, ok := first[t.Type]
+		if !ok {
+			order = append(order, t.Type)
+		}
+		if !ok || q.priorityOf(t) > q.priorityOf(q.pending[j]) {
+			first[t.Type] = i
+		}
+	}
+
+	if len(order) == 0 {
+		return -1
+	}
+	total, best := 0, ""
+	for _, typ := range order {
+		w, ok := q.weights[typ]
+		if !ok {
+			w = DefaultTypeWeight
+		}
+		total += w
+		q.credit[typ] += w
+		if best == "" || q.credit[typ] > q.credit[best] {
+			best = typ
+		}
+	}
+	q.credit[best] -= total
+
+	// Types without backlog don't bank credit for later bursts.
+	for typ := range q.credit {
+		if _, ok := first[typ]; !ok {
+			delete(q.credit, typ)
+		}
+	}
+	return first[best]
+}
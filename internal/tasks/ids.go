@@ -0,0 +1,125 @@
+package tasks
+
+// ID generation for tasks and runs
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IDGenerator produces unique IDs. Implementations must be safe for
+// concurrent use.
+type IDGenerator interface {
+	NewID() string
+}
+
+// UUIDv4 generates random RFC 9562 version 4 UUIDs.
+type UUIDv4 struct{}
+
+func (UUIDv4) NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+// UUIDv7 generates time-ordered RFC 9562 version 7 UUIDs: a 48-bit Unix
+// millisecond timestamp followed by random bits. IDs from one generator sort
+// in creation order, even within the same millisecond, which keeps B-tree
+// indexes (e.g. Postgres) append-mostly. Use a shared *UUIDv7.
+type UUIDv7 struct {
+	mu     sync.Mutex
+	lastMS int64
+	seq    uint16 // 12-bit counter in rand_a for IDs within one millisecond
+}
+
+func (g *UUIDv7) NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+
+	g.mu.Lock()
+	ms := time.Now().UnixMilli()
+	if ms <= g.lastMS {
+		ms = g.lastMS
+		g.seq++
+		if g.seq > 0x0fff {
+			// Counter exhausted: borrow the next millisecond.
+			ms++
+			g.seq = 0
+		}
+	} else {
+		g.seq = binary.BigEndian.Uint16(b[6:8]) & 0x07ff // random start, room to count
+	}
+	g.lastMS = ms
+	seq := g.seq
+	g.mu.Unlock()
+
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = 0x70 | byte(seq>>8)&0x0f
+	b[7] = byte(seq)
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+// HashIDs derives IDs deterministically as SHA-256(Seed, n) for n = 1, 2, ...
+// The same seed always yields the same sequence, which makes runs replayable.
+type HashIDs struct {
+	Seed string
+
+	mu sync.Mutex
+	n  uint64
+}
+
+func (g *HashIDs) NewID() string {
+	g.mu.Lock()
+	g.n++
+	n := g.n
+	g.mu.Unlock()
+
+	h := sha256.New()
+	h.Write([]byte(g.Seed))
+	var nb [8]byte
+	binary.BigEndian.PutUint64(nb[:], n)
+	h.Write(nb[:])
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Counter yields Prefix1, Prefix2, ... and is meant for tests.
+type Counter struct {
+	Prefix string
+
+	mu sync.Mutex
+	n  int
+}
+
+func (c *Counter) NewID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+	return c.Prefix + strconv.Itoa(c.n)
+}
+
+func formatUUID(b [16]byte) string {
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
@@ -0,0 +1,59 @@
+package tasks
+
+// Queue introspection for operators
+
+import "context"
+
+// Find returns copies of the pending tasks match accepts, in queue order.
+// The queue is snapshotted under its lock and match runs outside it, so a
+// slow predicate does not stall producers or workers, and changing the
+// returned tasks does not affect the queue. In-flight tasks are not
+// searched. Find stops early with ctx's error if ctx is done.
+func (q *MemoryQueue) Find(ctx context.Context, match func(Task) bool) ([]Task, error) {
+	q.mu.Lock()
+	snapshot := make([]Task, len(q.pending))
+	for i, t := range q.pending {
+		t.Payload = clonePayload(t.Payload)
+		snapshot[i] = t
+	}
+	q.mu.Unlock()
+
+	var out []Task
+	for i, t := range snapshot {
+		if i%64 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if match(t) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// clonePayload deep-copies the maps and slices of a payload.
+func clonePayload(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = cloneValue(v)
+	}
+	return out
+}
+
+func cloneValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		return clonePayload(x)
+	case []any:
+		out := make([]any, len(x))
+		for i, e := range x {
+			out[i] = cloneValue(e)
+		}
+		return out
+	}
+	return v
+}
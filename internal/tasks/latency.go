@@ -0,0 +1,118 @@
+package tasks
+
+// Queue latency histograms
+// Wait time (enqueue to dequeue) and service time (dequeue to ack) per task
+// type, for sizing the worker pool.
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the histogram upper bounds used when
+// WithLatencyBuckets is not given.
+var DefaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond, 25 * time.Millisecond, 100 * time.Millisecond,
+	250 * time.Millisecond, 500 * time.Millisecond, time.Second,
+	2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+	30 * time.Second, time.Minute, 5 * time.Minute,
+}
+
+// Histogram is a snapshot of a latency distribution. Counts[i] is the number
+// of observations of at most Bounds[i]; the last element counts those above
+// every bound. Counts are not cumulative.
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []uint64 // len(Bounds)+1
+	Count  uint64
+	Sum    time.Duration
+}
+
+// Mean is the average observation, or zero without any.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// WithLatencyBuckets sets the upper bounds of the wait and service time
+// histograms. They are sorted; duplicates are dropped.
+func WithLatencyBuckets(bounds ...time.Duration) QueueOption {
+	return func(q *MemoryQueue) {
+		b := slices.Clone(bounds)
+		slices.Sort(b)
+		q.counters.latency.bounds = slices.Compact(b)
+	}
+}
+
+// latencyCounters holds the histograms. Tasks recovered from a WAL have no
+// enqueue time and are left out of the wait histogram.
+type latencyCounters struct {
+	mu      sync.Mutex
+	bounds  []time.Duration // nil means DefaultLatencyBuckets
+	wait    map[string]*Histogram
+	service map[string]*Histogram
+}
+
+func (c *latencyCounters) observe(hists *map[string]*Histogram, taskType string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if *hists == nil {
+		*hists = make(map[string]*Histogram)
+	}
+	h := (*hists)[taskType]
+	if h == nil {
+		bounds := c.bounds
+		if bounds == nil {
+			bounds = DefaultLatencyBuckets
+		}
+		h = &Histogram{Bounds: bounds, Counts: make([]uint64, len(bounds)+1)}
+		(*hists)[taskType] = h
+	}
+	i, _ := slices.BinarySearch(h.Bounds, d)
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+func (c *latencyCounters) snapshot() (wait, service map[string]Histogram) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp := func(m map[string]*Histogram) map[string]Histogram {
+		out := make(map[string]Histogram, len(m))
+		for t, h := range m {
+			hc := *h
+			hc.Counts = slices.Clone(h.Counts)
+			out[t] = hc
+		}
+		return out
+	}
+	return cp(c.wait), cp(c.service)
+}
+
+func (c *latencyCounters) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wait, c.service = nil, nil
+}
+
+// stamp records when taskID entered its current stage. Caller must hold q.mu.
+func (q *MemoryQueue) stamp(taskID string) {
+	if q.stamps == nil {
+		q.stamps = make(map[string]time.Time)
+	}
+	q.stamps[taskID] = q.now()
+}
+
+// observeStage adds the time since taskID's stamp to hists and clears the
+// stamp. Caller must hold q.mu.
+func (q *MemoryQueue) observeStage(hists *map[string]*Histogram, t Task) {
+	at, ok := q.stamps[t.ID]
+	if !ok {
+		return
+	}
+	delete(q.stamps, t.ID)
+	q.counters.latency.observe(hists, t.Type, q.now().Sub(at))
+}
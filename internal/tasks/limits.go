@@ -0,0 +1,33 @@
+package tasks
+
+// Enqueue-time payload size guard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrPayloadTooLarge = errors.New("task payload too large")
+
+// WithMaxPayloadBytes rejects tasks whose JSON-encoded Payload exceeds n bytes
+// with ErrPayloadTooLarge. Zero means unlimited.
+func WithMaxPayloadBytes(n int) QueueOption {
+	return func(q *MemoryQueue) { q.maxPayload = n }
+}
+
+// checkPayload measures t.Payload the way serialization-based backends would
+// store it.
+func (q *MemoryQueue) checkPayload(t Task) error {
+	if q.maxPayload <= 0 || len(t.Payload) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(t.Payload)
+	if err != nil {
+		return fmt.Errorf("measure payload: %w", err)
+	}
+	if len(b) > q.maxPayload {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrPayloadTooLarge, len(b), q.maxPayload)
+	}
+	return nil
+}
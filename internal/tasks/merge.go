@@ -0,0 +1,73 @@
+package tasks
+
+// Merging results across partial retries
+
+// MergeStrategy decides how the Output maps of two attempts combine.
+type MergeStrategy int
+
+const (
+	// MergeAppend is MergeDeep, but []any values present in both are
+	// concatenated, prior's elements first. It is the zero value, so that
+	// attempts reporting their work under the same key (like agents.ItemAgent's
+	// "done" list) accumulate it.
+	MergeAppend MergeStrategy = iota
+	// MergeShallow copies prior's top-level keys and overwrites them with next's.
+	MergeShallow
+	// MergeDeep recurses into nested map[string]any values present in both.
+	MergeDeep
+)
+
+// MergeResults combines a prior partial result with the result of the retry
+// that continued it, using MergeAppend like the orchestrator does by default.
+// See MergeStrategy.MergeResults.
+func MergeResults(prior, next Result) Result {
+	return MergeAppend.MergeResults(prior, next)
+}
+
+// MergeResults combines prior and next. The outcome is deterministic: Status
+// and Err describe the latest attempt and come from next; TaskID comes from
+// prior; on any Output conflict the strategy cannot reconcile (differing
+// scalars, or a map against a non-map) next's value wins. Neither input is
+// modified.
+func (s MergeStrategy) MergeResults(prior, next Result) Result {
+	out := next
+	out.TaskID = prior.TaskID
+	if out.TaskID == "" {
+		out.TaskID = next.TaskID
+	}
+	out.Output = s.merge(prior.Output, next.Output)
+	return out
+}
+
+func (s MergeStrategy) merge(a, b map[string]any) map[string]any {
+	if a == nil && b == nil {
+		return nil
+	}
+	out := make(map[string]any, len(a)+len(b))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, bv := range b {
+		av, ok := out[k]
+		if !ok || s == MergeShallow {
+			out[k] = bv
+			continue
+		}
+		out[k] = s.mergeValue(av, bv)
+	}
+	return out
+}
+
+func (s MergeStrategy) mergeValue(a, b any) any {
+	switch bv := b.(type) {
+	case map[string]any:
+		if am, ok := a.(map[string]any); ok {
+			return s.merge(am, bv)
+		}
+	case []any:
+		if al, ok := a.([]any); ok && s == MergeAppend {
+			return append(append(make([]any, 0, len(al)+len(bv)), al...), bv...)
+		}
+	}
+	return b
+}
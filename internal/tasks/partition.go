@@ -0,0 +1,65 @@
+package tasks
+
+// Ordered partitions: per-key FIFO with one task in flight per key
+
+// WithPartitionKey enables ordered-partition mode. Tasks for which key returns
+// the same non-empty string form a partition: they are handed out in enqueue
+// order, and the next one is held back until the previous one is acked. This
+// is head-of-line blocking within a partition (a slow "reset" delays the
+// learner's "grade") while different partitions still run in parallel. Tasks
+// with an empty key are not ordered.
+func WithPartitionKey(key func(Task) string) QueueOption {
+	return func(q *MemoryQueue) {
+		q.partitionKey = key
+		q.busy = make(map[string]bool)
+	}
+}
+
+// PayloadPartition returns a partition-key extractor reading a string field of
+// the payload, e.g. PayloadPartition("learnerId").
+func PayloadPartition(field string) func(Task) string {
+	return func(t Task) string {
+		s, _ := t.Payload[field].(string)
+		return s
+	}
+}
+
+// partitionFilter returns the eligibility check for one pass over pending:
+// a task may go if its partition has nothing in flight and no earlier pending
+// task. It must be called once per index in ascending order. Caller must hold q.mu.
+func (q *MemoryQueue) partitionFilter() func(i int) bool {
+	seen := make(map[string]bool)
+	return func(i int) bool {
+		p := q.partitionKey(q.pending[i])
+		if p == "" {
+			return true
+		}
+		if q.busy[p] || seen[p] {
+			return false
+		}
+		seen[p] = true
+		return true
+	}
+}
+
+// holdPartition marks t's partition busy. Caller must hold q.mu.
+func (q *MemoryQueue) holdPartition(t Task) {
+	if q.partitionKey == nil {
+		return
+	}
+	if p := q.partitionKey(t); p != "" {
+		q.busy[p] = true
+	}
+}
+
+// releasePartition frees t's partition and wakes blocked dequeuers, since the
+// partition's next task may now go. Caller must hold q.mu.
+func (q *MemoryQueue) releasePartition(t Task) {
+	if q.partitionKey == nil {
+		return
+	}
+	if p := q.partitionKey(t); p != "" && q.busy[p] {
+		delete(q.busy, p)
+		q.signal()
+	}
+}
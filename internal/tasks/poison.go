@@ -0,0 +1,95 @@
+package tasks
+
+// Poison task detection
+// A task whose worker dies mid-execution is never acked; the WAL hands it
+// out again after a restart. A task that keeps taking its worker down with
+// it would do so forever, so the queue counts deliveries that were never
+// resolved and, past a threshold, parks the task in a poison store instead.
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoisoned is the reason recorded for tasks moved to a poison store. It
+// is distinct from any failure an agent can report: the task never finished.
+var ErrPoisoned = errors.New("task was delivered repeatedly without being resolved")
+
+// PoisonedTask is a task taken out of circulation.
+type PoisonedTask struct {
+	Task       Task
+	Deliveries int // dequeues that were never acked or dropped
+	Reason     error
+	At         time.Time
+}
+
+// PoisonStore keeps poisoned tasks for inspection and manual replay.
+type PoisonStore interface {
+	Put(ctx context.Context, p PoisonedTask) error
+}
+
+// MemoryPoisonStore is a threadsafe in-memory PoisonStore.
+type MemoryPoisonStore struct {
+	mu    sync.Mutex
+	tasks []PoisonedTask
+}
+
+func NewMemoryPoisonStore() *MemoryPoisonStore { return &MemoryPoisonStore{} }
+
+func (s *MemoryPoisonStore) Put(ctx context.Context, p PoisonedTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = append(s.tasks, p)
+	return nil
+}
+
+// Len returns the number of stored tasks.
+func (s *MemoryPoisonStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tasks)
+}
+
+// List returns the poisoned tasks in the order they were stored.
+func (s *MemoryPoisonStore) List() []PoisonedTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]PoisonedTask(nil), s.tasks...)
+}
+
+// WithPoisonThreshold moves a task to store, instead of redelivering it,
+// once it has been dequeued n times without an Ack or Drop in between. Such
+// deliveries only happen when the process dies with the task in flight, so
+// the check runs when OpenWALQueue recovers a log; delivery counts survive
+// compaction. Tasks the store rejects stay in the queue.
+func WithPoisonThreshold(n int, store PoisonStore) QueueOption {
+	return func(q *MemoryQueue) { q.poisonAfter, q.poison = n, store }
+}
+
+// quarantine moves every pending task at or over the poison threshold to the
+// poison store. Caller must hold q.mu or own q exclusively.
+func (q *MemoryQueue) quarantine() {
+	if q.poison == nil || q.poisonAfter <= 0 {
+		return
+	}
+	kept := q.pending[:0]
+	for _, t := range q.pending {
+		n := q.deliveries[t.ID]
+		if n < q.poisonAfter {
+			kept = append(kept, t)
+			continue
+		}
+		p := PoisonedTask{Task: t, Deliveries: n, Reason: ErrPoisoned, At: q.now()}
+		if q.poison.Put(context.Background(), p) != nil {
+			kept = append(kept, t)
+			continue
+		}
+		delete(q.deliveries, t.ID)
+		delete(q.seq, t.ID)
+		q.transition(t.ID, StatePoisoned)
+	}
+	clear(q.pending[len(kept):])
+	q.pending = kept
+}
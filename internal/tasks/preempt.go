@@ -0,0 +1,157 @@
+package tasks
+
+// Bounded pending list with priority preemption
+// Under overload a full queue makes room for a more important task by
+// evicting its least important pending one, so SLA-critical work keeps
+// flowing instead of blocking or being rejected.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrQueueFull = errors.New("queue is full")
+	// ErrEvicted is the reason recorded for tasks dead-lettered to make room
+	// for a higher-priority one.
+	ErrEvicted = errors.New("task was evicted by a higher-priority task")
+	// ErrDependencyEvicted fails the pending dependents of an evicted task.
+	ErrDependencyEvicted = errors.New("a dependency of the task was evicted")
+)
+
+// WithMaxPending bounds the queue to n pending tasks (in-flight ones do not
+// count). Enqueuing into a full queue evicts the pending task with the lowest
+// effective priority, the newest among equals, if the new task's priority is
+// strictly higher; otherwise the enqueue fails with ErrQueueFull. Evicted
+// tasks go to deadLetter, when set, with Reason ErrEvicted, end up
+// StateCancelled with CancelPreempted and are counted in Stats. Their
+// pending dependents cannot run any more and are dropped with them as
+// CancelFailFast. EnqueueEvicting also returns all of them to the caller,
+// who may be waiting on their results.
+//
+// The eviction only happens once the new task is accepted. If it cannot be
+// carried out after that, because deadLetter or the WAL fails, the victim
+// stays and the queue holds one task over n until the next eviction.
+func WithMaxPending(n int, deadLetter PoisonStore) QueueOption {
+	return func(q *MemoryQueue) { q.maxPending, q.deadLetter = n, deadLetter }
+}
+
+// Eviction is a task WithMaxPending removed from the queue: the evicted task
+// itself or one of its pending dependents.
+type Eviction struct {
+	Task   Task
+	Reason CancelReason // CancelPreempted or, for dependents, CancelFailFast
+}
+
+// Err is the error the task's result should carry.
+func (e Eviction) Err() error {
+	if e.Reason == CancelPreempted {
+		return ErrEvicted
+	}
+	return ErrDependencyEvicted
+}
+
+// EnqueueEvicting is Enqueue that also returns the tasks the enqueue
+// evicted, if any. See WithMaxPending.
+func (q *MemoryQueue) EnqueueEvicting(ctx context.Context, t Task) ([]Eviction, error) {
+	var out []Eviction
+	if err := q.enqueue(ctx, t, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// victim returns the index of the pending task to evict for t, -1 if the
+// queue has room, or ErrQueueFull if t outranks no pending task. t's own
+// dependencies, direct or not, are never evicted for it. Caller must hold
+// q.mu.
+func (q *MemoryQueue) victim(t Task) (int, error) {
+	if q.maxPending <= 0 || len(q.pending) < q.maxPending {
+		return -1, nil
+	}
+	needed := q.dependencyClosure(t.DependsOn)
+	victim := -1
+	for i, p := range q.pending {
+		if needed[p.ID] {
+			continue
+		}
+		if victim < 0 || q.priorityOf(p) <= q.priorityOf(q.pending[victim]) {
+			victim = i
+		}
+	}
+	if victim < 0 || q.priorityOf(t) <= q.priorityOf(q.pending[victim]) {
+		return -1, fmt.Errorf("%w: %d pending tasks, none below priority %d", ErrQueueFull, len(q.pending), q.priorityOf(t))
+	}
+	return victim, nil
+}
+
+// evict removes the pending task at index i together with its pending
+// dependents, deepest first, and appends them to *out if out is not nil.
+// It gives up, leaving the victim pending, if the victim cannot be
+// dead-lettered or its drop logged. Caller must hold q.mu.
+func (q *MemoryQueue) evict(ctx context.Context, i int, out *[]Eviction) {
+	v := q.pending[i]
+	if q.deadLetter != nil {
+		p := PoisonedTask{Task: v, Deliveries: q.deliveries[v.ID], Reason: ErrEvicted, At: q.now()}
+		if q.deadLetter.Put(ctx, p) != nil {
+			return
+		}
+	}
+	var evicted []Eviction
+	for _, d := range q.pendingDependents(v.ID) {
+		if !q.evictPending(d.ID, CancelFailFast) {
+			return
+		}
+		evicted = append(evicted, Eviction{Task: d, Reason: CancelFailFast})
+	}
+	if !q.evictPending(v.ID, CancelPreempted) {
+		return
+	}
+	q.counters.evictedFor(v.Type).Add(1)
+	if out != nil {
+		*out = append(append(*out, Eviction{Task: v, Reason: CancelPreempted}), evicted...)
+	}
+}
+
+// evictPending drops a pending task for reason and reports whether it
+// could log the drop. Caller must hold q.mu.
+func (q *MemoryQueue) evictPending(taskID string, reason CancelReason) bool {
+	for i, t := range q.pending {
+		if t.ID != taskID {
+			continue
+		}
+		if _, err := q.dropAt(i); err != nil {
+			return false
+		}
+		q.transitionReason(taskID, StateCancelled, reason)
+		return true
+	}
+	return true // already gone
+}
+
+// pendingDependents returns the pending tasks that depend on taskID,
+// directly or not, deepest dependents first. Caller must hold q.mu.
+func (q *MemoryQueue) pendingDependents(taskID string) []Task {
+	byID := make(map[string]Task, len(q.pending))
+	for _, t := range q.pending {
+		byID[t.ID] = t
+	}
+	var out []Task
+	seen := map[string]bool{taskID: true}
+	var walk func(id string)
+	walk = func(id string) {
+		for _, d := range q.deps.dependents[id] {
+			if seen[d] {
+				continue
+			}
+			seen[d] = true
+			walk(d)
+			if t, ok := byID[d]; ok {
+				out = append(out, t)
+			}
+		}
+	}
+	walk(taskID)
+	return out
+}
@@ -0,0 +1,27 @@
+package tasks
+
+// Computed task priorities
+
+// PriorityFunc computes a task's effective priority at enqueue time, e.g.
+// from tenant or learner metadata in its payload.
+type PriorityFunc func(Task) int
+
+// StaticPriority is the default: the task's own Priority field.
+func StaticPriority(t Task) int { return t.Priority }
+
+// WithPriorityFunc makes the queue set every enqueued task's Priority to
+// fn(t), so business rules apply without callers setting priorities by hand.
+// A nil fn keeps StaticPriority.
+func WithPriorityFunc(fn PriorityFunc) QueueOption {
+	return func(q *MemoryQueue) { q.priority = fn }
+}
+
+// PayloadBoost returns a PriorityFunc that adds boosts[v] to the static
+// priority, where v is the string payload field named field. For example
+// PayloadBoost("tenantTier", map[string]int{"premium": 100}).
+func PayloadBoost(field string, boosts map[string]int) PriorityFunc {
+	return func(t Task) int {
+		v, _ := t.Payload[field].(string)
+		return t.Priority + boosts[v]
+	}
+}
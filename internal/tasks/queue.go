@@ -4,18 +4,73 @@ package tasks
 // This file implements a task dispatch queue with in-memory implementation
 // and adapter interface for pluggable queue backends
 
-import "context"
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
 
 type Task struct {
 	ID      string
 	Type    string
 	Payload map[string]any
+
+	// DedupKey identifies logically identical tasks. When the queue has a
+	// dedup window, a second task with the same key inside the window is
+	// rejected with a *DuplicateError. Empty disables dedup for the task.
+	DedupKey string
+
+	// Priority orders pending tasks: higher goes first, equal ones FIFO.
+	// A queue with a PriorityFunc overwrites it at enqueue.
+	Priority int
+
+	// TenantID is the tenant the task runs for, used for per-tenant limits.
+	TenantID string
+
+	// GroupID ties tasks that are awaited together (a barrier). The first
+	// task enqueued for a group declares the group's GroupSize.
+	GroupID   string
+	GroupSize int
+
+	// DependsOn lists tasks that must be acked before this one is handed
+	// out. Only dependencies the queue currently holds, pending or in
+	// flight, are waited for; they inherit this task's priority if it is
+	// higher than theirs, so a high-priority chain is not starved.
+	DependsOn []string
+
+	// RequeuedFrom is the ID of the failed task this one is a corrected
+	// retry of, and Attempt counts such retries along the chain (zero for
+	// an original task).
+	RequeuedFrom string
+	Attempt      int
+
+	// RunID is the run the task descends from and ParentID the task whose
+	// result it follows up on, if any. A follow-up enqueued through the
+	// orchestrator with only a ParentID inherits the parent's run while it
+	// is in progress; see Spawn.
+	RunID    string
+	ParentID string
+}
+
+// Spawn returns child as a follow-up of t: same run, t as its parent.
+func (t Task) Spawn(child Task) Task {
+	child.RunID, child.ParentID = t.RunID, t.ID
+	return child
 }
 
 type Result struct {
 	TaskID string
+	Status string // "ok", "failed", "partial"
 	Output map[string]any
 	Err    error
+
+	// CancelReason is set on failed results of tasks that were cut short
+	// instead of failing on their own; acking one records the matching
+	// terminal state and reason.
+	CancelReason CancelReason
 }
 
 type Queue interface {
@@ -23,3 +78,423 @@ type Queue interface {
 	Dequeue(ctx context.Context) (Task, error)
 	Ack(ctx context.Context, taskID string, res Result) error
 }
+
+var (
+	ErrEmptyTaskID = errors.New("task must have a non-empty ID")
+	ErrUnknownTask = errors.New("unknown or already acked task")
+	ErrQueueClosed = errors.New("queue is closed")
+)
+
+// MemoryQueue is a priority-ordered FIFO in-memory Queue. Dequeued tasks
+// stay in-flight until they are acked. It is safe for concurrent use;
+// Dequeue blocks until a task is available or the context is done.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	pending  []Task
+	inflight map[string]Task
+	ready    chan struct{} // closed and replaced whenever a task is enqueued
+	drained  bool          // set by Drain; the queue accepts and hands out nothing
+	closing  bool          // set by Close; the queue only drains what it holds
+
+	weights map[string]int // weighted-fair mode when non-nil, see WithTypeWeights
+	credit  map[string]int // smooth weighted round-robin state per task type
+
+	priority PriorityFunc // optional, see WithPriorityFunc
+
+	partitionKey func(Task) string // ordered-partition mode when non-nil
+	busy         map[string]bool   // partitions with a task in flight
+
+	deps depGraph // see Task.DependsOn
+
+	deliveries  map[string]int // task ID -> dequeues not yet acked or dropped
+	poisonAfter int            // see WithPoisonThreshold
+	poison      PoisonStore
+
+	dedupWindow time.Duration
+	dedup       map[string]dedupEntry // DedupKey -> first task seen in window
+	lastSweep   time.Time
+
+	maxPending int         // see WithMaxPending; zero means unbounded
+	deadLetter PoisonStore // receives evicted tasks, see WithMaxPending
+
+	maxPayload int      // bytes; zero means unlimited
+	admitter   Admitter // optional, see WithAdmitter
+
+	states *StateStore // optional lifecycle tracking, see WithStateStore
+
+	counters queueCounters        // see Stats
+	stamps   map[string]time.Time // task ID -> enqueued (pending) or dequeued (in flight)
+	seq      map[string]uint64    // task ID -> enqueue order, while held
+	lastSeq  uint64
+
+	now   func() time.Time
+	wal   *wal // optional write-ahead log, see OpenWALQueue
+	typed bool // WAL payloads use EncodeTyped, see WithTypedPayloads
+}
+
+// QueueOption configures a MemoryQueue at construction.
+type QueueOption func(*MemoryQueue)
+
+// WithDedupWindow suppresses tasks whose DedupKey was already enqueued within d.
+func WithDedupWindow(d time.Duration) QueueOption {
+	return func(q *MemoryQueue) { q.dedupWindow = d }
+}
+
+// NewMemoryQueue creates an empty, non-durable in-memory queue.
+func NewMemoryQueue(opts ...QueueOption) *MemoryQueue {
+	q := &MemoryQueue{
+		inflight:   make(map[string]Task),
+		deliveries: make(map[string]int),
+		ready:      make(chan struct{}),
+		dedup:      make(map[string]dedupEntry),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Enqueue appends a task to the back of the queue.
+func (q *MemoryQueue) Enqueue(ctx context.Context, t Task) error {
+	return q.enqueue(ctx, t, nil)
+}
+
+// enqueue is Enqueue, reporting evictions to *evicted (see evict).
+func (q *MemoryQueue) enqueue(ctx context.Context, t Task, evicted *[]Eviction) error {
+	if t.ID == "" {
+		return ErrEmptyTaskID
+	}
+	if q.priority != nil {
+		t.Priority = q.priority(t)
+	}
+	if err := q.checkPayload(t); err != nil {
+		return err
+	}
+	if err := q.admit(ctx, t); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.drained {
+		return ErrQueueDrained
+	}
+	if q.closing {
+		return ErrQueueClosed
+	}
+	if err := q.checkDuplicate(t); err != nil {
+		return err
+	}
+	victim, err := q.victim(t)
+	if err != nil {
+		return err
+	}
+	if err := q.checkTransition(t.ID, StatePending); err != nil {
+		return err
+	}
+	rec, err := q.enqueueRecord(t)
+	if err != nil {
+		return err
+	}
+	if err := q.log(rec); err != nil {
+		return err
+	}
+	q.restoreState(t.ID, StatePending)
+	q.pending = append(q.pending, t)
+	q.sequence(t.ID)
+	q.stamp(t.ID)
+	q.trackDeps(t)
+	q.remember(t)
+	if victim >= 0 {
+		q.evict(ctx, victim, evicted)
+	}
+	q.signal()
+	return nil
+}
+
+// Dequeue pops the next pending task and marks it in-flight. Tasks come out
+// highest Priority first, oldest first among equals, unless a scheduling mode
+// such as weighted-fair is configured. Once the queue is closed and has no
+// pending tasks left, Dequeue returns ErrQueueClosed.
+func (q *MemoryQueue) Dequeue(ctx context.Context) (Task, error) {
+	return q.DequeueMatching(ctx, nil)
+}
+
+// DequeueMatching is Dequeue limited to the tasks match accepts, e.g. those
+// of certain types; the others stay pending, in order, for other callers.
+// Once the queue is closed and no pending task matches, it returns
+// ErrQueueClosed. match runs under the queue's lock, so it must be cheap and
+// must not call back into the queue. A nil match accepts every task.
+func (q *MemoryQueue) DequeueMatching(ctx context.Context, match func(Task) bool) (Task, error) {
+	for {
+		q.mu.Lock()
+		if q.drained {
+			q.mu.Unlock()
+			return Task{}, ErrQueueDrained
+		}
+		if q.closing && !q.anyPending(match) {
+			q.mu.Unlock()
+			return Task{}, ErrQueueClosed
+		}
+		if i := q.next(match); i >= 0 {
+			t := q.pending[i]
+			if q.checkTransition(t.ID, StateDispatched) != nil {
+				// Resolved through a shared StateStore while pending:
+				// drop it rather than deliver it.
+				freed, err := q.dropAt(i)
+				if freed {
+					q.signal()
+				}
+				q.mu.Unlock()
+				if err != nil {
+					return Task{}, err
+				}
+				continue
+			}
+			if err := q.log(walRecord{Op: opDequeue, ID: t.ID}); err != nil {
+				q.mu.Unlock()
+				return Task{}, err
+			}
+			q.removePending(i)
+			q.observeStage(&q.counters.latency.wait, t)
+			q.stamp(t.ID)
+			q.inflight[t.ID] = t
+			q.deliveries[t.ID]++
+			q.holdPartition(t)
+			q.restoreState(t.ID, StateDispatched)
+			q.mu.Unlock()
+			return t, nil
+		}
+		ready := q.ready
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Task{}, ctx.Err()
+		case <-ready:
+		}
+	}
+}
+
+// Ack resolves an in-flight task. Acking an unknown task returns ErrUnknownTask.
+// If the StateStore rejects the final state, the task is still resolved and
+// Ack returns the ErrInvalidTransition.
+func (q *MemoryQueue) Ack(ctx context.Context, taskID string, res Result) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.inflight[taskID]; !ok {
+		return ErrUnknownTask
+	}
+	final := finalState(res)
+	stateErr := q.checkTransition(taskID, final)
+	if err := q.log(walRecord{Op: opAck, ID: taskID}); err != nil {
+		return err
+	}
+	t := q.inflight[taskID]
+	q.observeStage(&q.counters.latency.service, t)
+	delete(q.inflight, taskID)
+	delete(q.deliveries, taskID)
+	delete(q.seq, taskID)
+	q.releasePartition(t)
+	if q.forgetDeps(taskID) {
+		q.signal() // its dependents may go now
+	}
+	if stateErr == nil {
+		q.transitionReason(taskID, final, res.CancelReason)
+	}
+	var err error
+	if q.closing {
+		err = q.closeIfEmpty()
+	} else {
+		err = q.maybeCompact()
+	}
+	return errors.Join(stateErr, err)
+}
+
+// Len returns the number of pending and in-flight tasks.
+func (q *MemoryQueue) Len() (pending, inflight int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending), len(q.inflight)
+}
+
+// Holds reports whether taskID is pending or in flight, i.e. neither acked
+// nor dropped yet.
+func (q *MemoryQueue) Holds(taskID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.inflight[taskID]; ok {
+		return true
+	}
+	return slices.ContainsFunc(q.pending, func(t Task) bool { return t.ID == taskID })
+}
+
+// Close starts a graceful shutdown. From then on Enqueue fails with
+// ErrQueueClosed, while Dequeue keeps handing out pending tasks, and Ack
+// keeps resolving in-flight ones, until none are left. Blocked Dequeue calls
+// wake up and return ErrQueueClosed once nothing is pending. The WAL, if
+// any, is released as soon as the queue holds no tasks: right away when it
+// is already empty, else once the last task is acked or dropped (an Ack
+// returns the error of releasing it). Closing a closed queue does nothing.
+func (q *MemoryQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closing {
+		return nil
+	}
+	q.closing = true
+	q.signal()
+	return q.closeIfEmpty()
+}
+
+// closeIfEmpty releases the WAL of a closing queue that holds no more tasks.
+// Caller must hold q.mu.
+func (q *MemoryQueue) closeIfEmpty() error {
+	if q.wal == nil || len(q.pending) > 0 || len(q.inflight) > 0 {
+		return nil
+	}
+	w := q.wal
+	q.wal = nil
+	return w.close()
+}
+
+// next returns the index in pending of the task to hand out among those
+// match accepts, or -1. Caller must hold q.mu.
+func (q *MemoryQueue) next(match func(Task) bool) int {
+	if len(q.pending) == 0 {
+		return -1
+	}
+	var eligible func(i int) bool // nil: every pending task may go
+	if q.partitionKey != nil {
+		eligible = q.partitionFilter()
+	}
+	if deps := q.dependencyFilter(); deps != nil {
+		if part := eligible; part != nil {
+			// Both run for every index, as partitionFilter requires.
+			eligible = func(i int) bool { return part(i) && deps(i) }
+		} else {
+			eligible = deps
+		}
+	}
+	if match != nil {
+		if prev := eligible; prev != nil {
+			// prev first: partitionFilter must still see every index.
+			eligible = func(i int) bool { return prev(i) && match(q.pending[i]) }
+		} else {
+			eligible = func(i int) bool { return match(q.pending[i]) }
+		}
+	}
+	if q.weights != nil {
+		return q.nextFair(eligible)
+	}
+	best := -1
+	for i, t := range q.pending {
+		if eligible != nil && !eligible(i) {
+			continue
+		}
+		if best < 0 || q.priorityOf(t) > q.priorityOf(q.pending[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// sequence numbers a task the queue takes in, so that in-flight tasks, kept
+// in a map, can be listed in the order they were enqueued. Caller must hold
+// q.mu or own q exclusively.
+func (q *MemoryQueue) sequence(taskID string) {
+	if q.seq == nil {
+		q.seq = make(map[string]uint64)
+	}
+	q.lastSeq++
+	q.seq[taskID] = q.lastSeq
+}
+
+// inflightTasks returns the in-flight tasks in enqueue order. Caller must
+// hold q.mu or own q exclusively.
+func (q *MemoryQueue) inflightTasks() []Task {
+	out := make([]Task, 0, len(q.inflight))
+	for _, t := range q.inflight {
+		out = append(out, t)
+	}
+	slices.SortFunc(out, func(a, b Task) int { return cmp.Compare(q.seq[a.ID], q.seq[b.ID]) })
+	return out
+}
+
+// anyPending reports whether a pending task matches (nil: any at all).
+// Caller must hold q.mu.
+func (q *MemoryQueue) anyPending(match func(Task) bool) bool {
+	if match == nil {
+		return len(q.pending) > 0
+	}
+	for _, t := range q.pending {
+		if match(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// removePending deletes pending[i]. Caller must hold q.mu.
+func (q *MemoryQueue) removePending(i int) {
+	if i == 0 {
+		q.pending[0] = Task{}
+		q.pending = q.pending[1:]
+		return
+	}
+	copy(q.pending[i:], q.pending[i+1:])
+	q.pending[len(q.pending)-1] = Task{}
+	q.pending = q.pending[:len(q.pending)-1]
+}
+
+// dropAt logs a drop of q.pending[i] and removes it, reporting whether that
+// unblocked dependents. Caller must hold q.mu.
+func (q *MemoryQueue) dropAt(i int) (bool, error) {
+	taskID := q.pending[i].ID
+	if err := q.log(walRecord{Op: opDrop, ID: taskID}); err != nil {
+		return false, err
+	}
+	q.removePending(i)
+	delete(q.deliveries, taskID)
+	delete(q.stamps, taskID)
+	delete(q.seq, taskID)
+	return q.forgetDeps(taskID), nil
+}
+
+// signal wakes every blocked Dequeue. Caller must hold q.mu.
+func (q *MemoryQueue) signal() {
+	close(q.ready)
+	q.ready = make(chan struct{})
+}
+
+// Drop removes a task that is still pending and records the given terminal
+// state (StateExpired or StateCancelled) for it. It returns false if the task
+// is not pending, e.g. because a worker already dequeued it.
+func (q *MemoryQueue) Drop(taskID string, state TaskState) bool {
+	return q.drop(taskID, state, CancelNone)
+}
+
+func (q *MemoryQueue) drop(taskID string, state TaskState, reason CancelReason) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, t := range q.pending {
+		if t.ID != taskID {
+			continue
+		}
+		freed, err := q.dropAt(i)
+		if err != nil {
+			return false
+		}
+		if freed {
+			q.signal()
+		}
+		q.transitionReason(taskID, state, reason)
+		if q.closing {
+			q.closeIfEmpty()
+		}
+		return true
+	}
+	return false
+}
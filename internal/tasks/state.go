@@ -0,0 +1,190 @@
+package tasks
+
+// Task lifecycle state machine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type TaskState int
+
+const (
+	StatePending    TaskState = iota + 1 // enqueued, waiting for a worker
+	StateDispatched                      // dequeued, not yet executing
+	StateRunning                         // an agent is executing it
+	StateSucceeded                       // acked with status ok or partial
+	StateFailed                          // acked with status failed
+	StateExpired                         // dropped after a deadline passed
+	StateCancelled                       // dropped on request
+	StatePoisoned                        // moved to the poison store, see WithPoisonThreshold
+)
+
+func (s TaskState) String() string {
+	switch s {
+	case StatePending:
+		return "pending"
+	case StateDispatched:
+		return "dispatched"
+	case StateRunning:
+		return "running"
+	case StateSucceeded:
+		return "succeeded"
+	case StateFailed:
+		return "failed"
+	case StateExpired:
+		return "expired"
+	case StateCancelled:
+		return "cancelled"
+	case StatePoisoned:
+		return "poisoned"
+	}
+	return fmt.Sprintf("TaskState(%d)", int(s))
+}
+
+// Terminal reports whether no further transitions are expected.
+func (s TaskState) Terminal() bool {
+	return s == StateSucceeded || s == StateExpired || s == StateCancelled || s == StatePoisoned
+}
+
+var ErrInvalidTransition = errors.New("invalid task state transition")
+
+// transitions lists the allowed moves. Failed is not terminal because a failed
+// task may be re-enqueued; dispatched/running may return to pending when a
+// task is redelivered.
+var transitions = map[TaskState][]TaskState{
+	0:               {StatePending, StatePoisoned},
+	StatePending:    {StateDispatched, StateExpired, StateCancelled, StatePoisoned},
+	StateDispatched: {StateRunning, StatePending, StateSucceeded, StateFailed, StateExpired, StateCancelled, StatePoisoned},
+	StateRunning:    {StateSucceeded, StateFailed, StatePending, StateExpired, StateCancelled, StatePoisoned},
+	StateFailed:     {StatePending},
+}
+
+// StateStore tracks the lifecycle state of every task it has seen. It is safe
+// for concurrent use.
+type StateStore struct {
+	mu      sync.RWMutex
+	states  map[string]TaskState
+	reasons map[string]CancelReason // set while the state came with a reason
+}
+
+func NewStateStore() *StateStore {
+	return &StateStore{states: make(map[string]TaskState), reasons: make(map[string]CancelReason)}
+}
+
+// Transition moves taskID to the given state, rejecting moves the lifecycle
+// does not allow with ErrInvalidTransition.
+func (s *StateStore) Transition(taskID string, to TaskState) error {
+	return s.TransitionReason(taskID, to, CancelNone)
+}
+
+// TransitionReason is Transition recording why the task was cancelled, for
+// moves to StateCancelled or StateExpired. See Reason.
+func (s *StateStore) TransitionReason(taskID string, to TaskState, reason CancelReason) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.allowed(taskID, to); err != nil {
+		return err
+	}
+	s.set(taskID, to, reason)
+	return nil
+}
+
+// allowed rejects moving taskID to to. Caller must hold s.mu.
+func (s *StateStore) allowed(taskID string, to TaskState) error {
+	from := s.states[taskID]
+	for _, ok := range transitions[from] {
+		if ok == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: task %s %v -> %v", ErrInvalidTransition, taskID, from, to)
+}
+
+// set records to for taskID unconditionally. Caller must hold s.mu.
+func (s *StateStore) set(taskID string, to TaskState, reason CancelReason) {
+	s.states[taskID] = to
+	if reason != CancelNone {
+		s.reasons[taskID] = reason
+	} else {
+		delete(s.reasons, taskID)
+	}
+}
+
+// State returns the current state of taskID.
+func (s *StateStore) State(taskID string) (TaskState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.states[taskID]
+	return st, ok
+}
+
+// Reason returns why taskID was cancelled, or CancelNone if its current
+// state came without a reason.
+func (s *StateStore) Reason(taskID string) CancelReason {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.reasons[taskID]
+}
+
+// Forget drops taskID, e.g. once its terminal state has been reported.
+func (s *StateStore) Forget(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, taskID)
+	delete(s.reasons, taskID)
+}
+
+// WithStateStore makes the queue record pending, dispatched and the final
+// state (from the acked result's status) for every task. Share the store with
+// the orchestrator so it can record running. OpenWALQueue marks every task it
+// recovers as pending again.
+func WithStateStore(s *StateStore) QueueOption {
+	return func(q *MemoryQueue) { q.states = s }
+}
+
+// transition records a state change if a store is configured.
+func (q *MemoryQueue) transition(taskID string, to TaskState) error {
+	return q.transitionReason(taskID, to, CancelNone)
+}
+
+func (q *MemoryQueue) transitionReason(taskID string, to TaskState, reason CancelReason) error {
+	if q.states == nil {
+		return nil
+	}
+	return q.states.TransitionReason(taskID, to, reason)
+}
+
+// checkTransition is transition without the move, for callers that must
+// know it will succeed before they log the change it records.
+func (q *MemoryQueue) checkTransition(taskID string, to TaskState) error {
+	if q.states == nil {
+		return nil
+	}
+	q.states.mu.RLock()
+	defer q.states.mu.RUnlock()
+	return q.states.allowed(taskID, to)
+}
+
+// restoreState records to for taskID without checking the move, for state
+// the WAL already committed to.
+func (q *MemoryQueue) restoreState(taskID string, to TaskState) {
+	if q.states == nil {
+		return
+	}
+	q.states.mu.Lock()
+	defer q.states.mu.Unlock()
+	q.states.set(taskID, to, CancelNone)
+}
+
+// finalState maps an acked result's status to its terminal state.
+func finalState(res Result) TaskState {
+	if res.CancelReason != CancelNone {
+		return res.CancelReason.State()
+	}
+	if res.Status == "failed" || (res.Status == "" && res.Err != nil) {
+		return StateFailed
+	}
+	return StateSucceeded
+}
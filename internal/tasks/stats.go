@@ -0,0 +1,91 @@
+package tasks
+
+// Queue counters
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DedupCounts is how the dedup window treated one task type's tasks.
+type DedupCounts struct {
+	Hits   uint64 // duplicates suppressed
+	Misses uint64 // tasks with a DedupKey that were enqueued
+	Reused uint64 // duplicates answered with the original's result
+}
+
+// QueueStats is a snapshot of a MemoryQueue's counters.
+type QueueStats struct {
+	Dedup   map[string]DedupCounts // task type -> counts
+	Evicted map[string]uint64      // task type -> tasks evicted, see WithMaxPending
+
+	// Wait is the time from enqueue to dequeue and Service the time from
+	// dequeue to ack, per task type.
+	Wait    map[string]Histogram
+	Service map[string]Histogram
+}
+
+type dedupCounter struct {
+	hits, misses, reused atomic.Uint64
+}
+
+// queueCounters are updated without q.mu, so Stats never waits on the queue.
+type queueCounters struct {
+	dedup   sync.Map // task type -> *dedupCounter
+	evicted sync.Map // task type -> *atomic.Uint64
+	latency latencyCounters
+}
+
+func (c *queueCounters) dedupFor(taskType string) *dedupCounter {
+	if v, ok := c.dedup.Load(taskType); ok {
+		return v.(*dedupCounter)
+	}
+	v, _ := c.dedup.LoadOrStore(taskType, new(dedupCounter))
+	return v.(*dedupCounter)
+}
+
+func (c *queueCounters) evictedFor(taskType string) *atomic.Uint64 {
+	if v, ok := c.evicted.Load(taskType); ok {
+		return v.(*atomic.Uint64)
+	}
+	v, _ := c.evicted.LoadOrStore(taskType, new(atomic.Uint64))
+	return v.(*atomic.Uint64)
+}
+
+// RecordDedupReuse counts a duplicate of taskType that was served the
+// original task's result, e.g. by the orchestrator's EnqueueOrGetResult.
+func (q *MemoryQueue) RecordDedupReuse(taskType string) {
+	q.counters.dedupFor(taskType).reused.Add(1)
+}
+
+// Stats returns the current counters.
+func (q *MemoryQueue) Stats() QueueStats {
+	out := QueueStats{Dedup: make(map[string]DedupCounts), Evicted: make(map[string]uint64)}
+	q.counters.dedup.Range(func(k, v any) bool {
+		c := v.(*dedupCounter)
+		out.Dedup[k.(string)] = DedupCounts{Hits: c.hits.Load(), Misses: c.misses.Load(), Reused: c.reused.Load()}
+		return true
+	})
+	q.counters.evicted.Range(func(k, v any) bool {
+		out.Evicted[k.(string)] = v.(*atomic.Uint64).Load()
+		return true
+	})
+	out.Wait, out.Service = q.counters.latency.snapshot()
+	return out
+}
+
+// ResetStats zeroes every counter, e.g. after a dedup window change.
+func (q *MemoryQueue) ResetStats() {
+	q.counters.dedup.Range(func(_, v any) bool {
+		c := v.(*dedupCounter)
+		c.hits.Store(0)
+		c.misses.Store(0)
+		c.reused.Store(0)
+		return true
+	})
+	q.counters.evicted.Range(func(_, v any) bool {
+		v.(*atomic.Uint64).Store(0)
+		return true
+	})
+	q.counters.latency.reset()
+}
@@ -0,0 +1,167 @@
+package tasks
+
+// Result store (in-memory + adapter interface)
+// Keeps the latest Result per task so callers can look results up after ack.
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type ResultStore interface {
+	Put(ctx context.Context, res Result) error
+	Get(ctx context.Context, taskID string) (Result, bool, error)
+}
+
+// EvictionOrder picks which results a retention policy drops first.
+type EvictionOrder int
+
+const (
+	EvictOldest EvictionOrder = iota // least recently stored
+	EvictLRU                         // least recently stored or read
+)
+
+// DefaultGCInterval is how often the background GC runs when
+// ResultRetention.GCInterval is zero.
+const DefaultGCInterval = time.Minute
+
+// ResultRetention bounds a MemoryResultStore. Zero fields disable that bound.
+type ResultRetention struct {
+	MaxAge   time.Duration // drop results not stored (EvictLRU: nor read) for this long
+	MaxCount int           // keep at most this many results
+	Order    EvictionOrder
+
+	// GCInterval is how often a background goroutine applies MaxAge; zero
+	// means DefaultGCInterval. MaxCount is enforced on every Put.
+	GCInterval time.Duration
+}
+
+// ResultStoreOption configures a MemoryResultStore at construction.
+type ResultStoreOption func(*MemoryResultStore)
+
+// WithResultRetention evicts results as r says. With a MaxAge the store runs
+// a GC goroutine until Close.
+func WithResultRetention(r ResultRetention) ResultStoreOption {
+	return func(s *MemoryResultStore) { s.retention = r }
+}
+
+// MemoryResultStore is a threadsafe in-memory ResultStore. A later Put for the
+// same task (e.g. a retry after a partial result) replaces the earlier one.
+// Without a retention policy it keeps every result forever.
+type MemoryResultStore struct {
+	mu      sync.Mutex
+	results map[string]*list.Element // task ID -> element of order
+	order   *list.List               // *retainedResult, next to evict at the front
+
+	retention ResultRetention
+	now       func() time.Time
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+type retainedResult struct {
+	res  Result
+	used time.Time // stored, or under EvictLRU last read
+}
+
+func NewMemoryResultStore(opts ...ResultStoreOption) *MemoryResultStore {
+	s := &MemoryResultStore{
+		results: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.retention.MaxAge > 0 {
+		go s.gcLoop()
+	}
+	return s
+}
+
+func (s *MemoryResultStore) Put(ctx context.Context, res Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.results[res.TaskID]; ok {
+		s.order.Remove(e)
+	}
+	s.results[res.TaskID] = s.order.PushBack(&retainedResult{res: res, used: s.now()})
+	if limit := s.retention.MaxCount; limit > 0 {
+		for s.order.Len() > limit {
+			s.evict(s.order.Front())
+		}
+	}
+	return nil
+}
+
+func (s *MemoryResultStore) Get(ctx context.Context, taskID string) (Result, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.results[taskID]
+	if !ok {
+		return Result{}, false, nil
+	}
+	sr := e.Value.(*retainedResult)
+	if s.retention.Order == EvictLRU {
+		sr.used = s.now()
+		s.order.MoveToBack(e)
+	}
+	return sr.res, true, nil
+}
+
+// Len returns the number of stored results.
+func (s *MemoryResultStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// GC evicts every result that is past the retention's MaxAge at now and
+// returns how many it removed. The background GC calls it with the current
+// time; tests can call it directly.
+func (s *MemoryResultStore) GC(now time.Time) int {
+	if s.retention.MaxAge <= 0 {
+		return 0
+	}
+	cutoff := now.Add(-s.retention.MaxAge)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for e := s.order.Front(); e != nil && e.Value.(*retainedResult).used.Before(cutoff); e = s.order.Front() {
+		s.evict(e)
+		n++
+	}
+	return n
+}
+
+// Close stops the background GC. The store stays usable.
+func (s *MemoryResultStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *MemoryResultStore) gcLoop() {
+	every := s.retention.GCInterval
+	if every <= 0 {
+		every = DefaultGCInterval
+	}
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-tick.C:
+			s.GC(s.now())
+		}
+	}
+}
+
+// evict removes e. Caller must hold s.mu.
+func (s *MemoryResultStore) evict(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.results, e.Value.(*retainedResult).res.TaskID)
+}
@@ -0,0 +1,205 @@
+package tasks
+
+// Type-preserving JSON for map[string]any
+// Plain JSON turns ints into float64, time.Time into strings and []byte into
+// base64 strings. The typed encoding wraps such values with a type hint,
+// {"$t": "int64", "v": "42"}, so they decode back to the same Go type.
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// typeKey marks a wrapped value. Maps that themselves use the key are wrapped
+// as "map" so they cannot be mistaken for a hint.
+const typeKey = "$t"
+
+// EncodeTyped encodes m as JSON with type hints for values JSON cannot carry
+// faithfully: signed and unsigned integers of every width, float32,
+// time.Time and []byte, at any depth inside map[string]any and []any. Other
+// values are encoded as encoding/json would.
+func EncodeTyped(m map[string]any) ([]byte, error) {
+	v, err := typedValue(m)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// DecodeTyped reverses EncodeTyped. Untagged numbers decode as float64, as
+// with encoding/json.
+func DecodeTyped(b []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	v, err := untypedValue(raw)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]any)
+	if !ok && v != nil {
+		return nil, fmt.Errorf("typed payload is %T, not an object", v)
+	}
+	return m, nil
+}
+
+func hint(typ string, v any) map[string]any { return map[string]any{typeKey: typ, "v": v} }
+
+func typedValue(v any) (any, error) {
+	switch x := v.(type) {
+	case map[string]any:
+		if x == nil {
+			return nil, nil
+		}
+		out := make(map[string]any, len(x))
+		for k, e := range x {
+			tv, err := typedValue(e)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = tv
+		}
+		if _, clash := x[typeKey]; clash {
+			return hint("map", out), nil
+		}
+		return out, nil
+	case []any:
+		if x == nil {
+			return nil, nil
+		}
+		out := make([]any, len(x))
+		for i, e := range x {
+			tv, err := typedValue(e)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = tv
+		}
+		return out, nil
+	case int:
+		return hint("int", strconv.FormatInt(int64(x), 10)), nil
+	case int8:
+		return hint("int8", strconv.FormatInt(int64(x), 10)), nil
+	case int16:
+		return hint("int16", strconv.FormatInt(int64(x), 10)), nil
+	case int32:
+		return hint("int32", strconv.FormatInt(int64(x), 10)), nil
+	case int64:
+		return hint("int64", strconv.FormatInt(x, 10)), nil
+	case uint:
+		return hint("uint", strconv.FormatUint(uint64(x), 10)), nil
+	case uint8:
+		return hint("uint8", strconv.FormatUint(uint64(x), 10)), nil
+	case uint16:
+		return hint("uint16", strconv.FormatUint(uint64(x), 10)), nil
+	case uint32:
+		return hint("uint32", strconv.FormatUint(uint64(x), 10)), nil
+	case uint64:
+		return hint("uint64", strconv.FormatUint(x, 10)), nil
+	case float32:
+		return hint("float32", strconv.FormatFloat(float64(x), 'g', -1, 32)), nil
+	case time.Time:
+		return hint("time", x.Format(time.RFC3339Nano)), nil
+	case []byte:
+		return hint("bytes", base64.StdEncoding.EncodeToString(x)), nil
+	}
+	return v, nil
+}
+
+func untypedValue(v any) (any, error) {
+	switch x := v.(type) {
+	case json.Number:
+		return x.Float64()
+	case []any:
+		for i, e := range x {
+			uv, err := untypedValue(e)
+			if err != nil {
+				return nil, err
+			}
+			x[i] = uv
+		}
+		return x, nil
+	case map[string]any:
+		typ, tagged := x[typeKey].(string)
+		if !tagged {
+			for k, e := range x {
+				uv, err := untypedValue(e)
+				if err != nil {
+					return nil, err
+				}
+				x[k] = uv
+			}
+			return x, nil
+		}
+		return unhint(typ, x["v"])
+	}
+	return v, nil
+}
+
+func unhint(typ string, v any) (any, error) {
+	if typ == "map" {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("typed map holds %T", v)
+		}
+		for k, e := range m {
+			uv, err := untypedValue(e)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = uv
+		}
+		return m, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("typed %s holds %T, want string", typ, v)
+	}
+	signed := func(bits int) (int64, error) { return strconv.ParseInt(s, 10, bits) }
+	unsigned := func(bits int) (uint64, error) { return strconv.ParseUint(s, 10, bits) }
+	switch typ {
+	case "int":
+		n, err := signed(strconv.IntSize)
+		return int(n), err
+	case "int8":
+		n, err := signed(8)
+		return int8(n), err
+	case "int16":
+		n, err := signed(16)
+		return int16(n), err
+	case "int32":
+		n, err := signed(32)
+		return int32(n), err
+	case "int64":
+		return signed(64)
+	case "uint":
+		n, err := unsigned(strconv.IntSize)
+		return uint(n), err
+	case "uint8":
+		n, err := unsigned(8)
+		return uint8(n), err
+	case "uint16":
+		n, err := unsigned(16)
+		return uint16(n), err
+	case "uint32":
+		n, err := unsigned(32)
+		return uint32(n), err
+	case "uint64":
+		return unsigned(64)
+	case "float32":
+		f, err := strconv.ParseFloat(s, 32)
+		return float32(f), err
+	case "time":
+		return time.Parse(time.RFC3339Nano, s)
+	case "bytes":
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return nil, fmt.Errorf("unknown type hint %q", typ)
+}
@@ -0,0 +1,350 @@
+package tasks
+
+// Write-ahead log for the in-memory queue
+// Every enqueue/dequeue/ack is appended to a JSON-lines file before it is
+// applied, so a restarted process can replay the log and recover its tasks.
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SyncPolicy controls how often the WAL is fsynced to disk.
+type SyncPolicy int
+
+const (
+	SyncAlways SyncPolicy = iota // fsync after every record; nothing acknowledged is lost
+	SyncBatch                    // fsync every walBatchSize records and on compaction/close
+	SyncNever                    // leave flushing to the OS
+)
+
+const (
+	walBatchSize    = 64
+	walCompactAfter = 1024 // acks between automatic compactions
+)
+
+// ErrCorruptWAL is returned by OpenWALQueue for a log that is damaged other
+// than by a torn final write.
+var ErrCorruptWAL = errors.New("write-ahead log is corrupt")
+
+const (
+	opEnqueue = "enq"
+	opDequeue = "deq"
+	opAck     = "ack"
+	opDrop    = "drop"
+)
+
+type walRecord struct {
+	Op   string `json:"op"`
+	Task *Task  `json:"task,omitempty"`
+	ID   string `json:"id,omitempty"`
+
+	// Payload replaces Task.Payload in typed mode, see WithTypedPayloads.
+	Payload json.RawMessage `json:"payload,omitempty"`
+
+	// Deliveries carries an enqueued task's unresolved dequeues across
+	// compaction, see WithPoisonThreshold.
+	Deliveries int `json:"deliveries,omitempty"`
+}
+
+// WithTypedPayloads makes the WAL store task payloads with EncodeTyped, so
+// ints, time.Time and []byte come back from a replay with their Go types.
+// Replay understands both encodings, so the option can be turned on for an
+// existing log.
+func WithTypedPayloads() QueueOption {
+	return func(q *MemoryQueue) { q.typed = true }
+}
+
+// enqueueRecord builds the log record for t. Caller must hold q.mu.
+func (q *MemoryQueue) enqueueRecord(t Task) (walRecord, error) {
+	if !q.typed || q.wal == nil || t.Payload == nil {
+		return walRecord{Op: opEnqueue, Task: &t}, nil
+	}
+	b, err := EncodeTyped(t.Payload)
+	if err != nil {
+		return walRecord{}, err
+	}
+	t.Payload = nil
+	return walRecord{Op: opEnqueue, Task: &t, Payload: b}, nil
+}
+
+type wal struct {
+	path     string
+	policy   SyncPolicy
+	f        *os.File
+	unsynced int
+	acked    int // acks since the last compaction
+}
+
+// OpenWALQueue opens (or creates) the log at path, replays it and returns a
+// queue whose every mutation is logged before it takes effect.
+//
+// Tasks that were in-flight when the previous process stopped are moved back
+// to the front of the pending list: their workers are gone, so they are
+// redelivered (at-least-once). The log is compacted right after recovery.
+// A log damaged anywhere but in its final record fails with ErrCorruptWAL.
+func OpenWALQueue(path string, policy SyncPolicy, opts ...QueueOption) (*MemoryQueue, error) {
+	q := NewMemoryQueue(opts...)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	good, order, err := q.replay(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// Drop a torn final record left by a crash mid-write.
+	if err := f.Truncate(good); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(good, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// Redeliver tasks whose workers died with the previous process.
+	if len(q.inflight) > 0 {
+		recovered := make([]Task, 0, len(q.inflight)+len(q.pending))
+		for _, id := range order {
+			if t, ok := q.inflight[id]; ok {
+				recovered = append(recovered, t)
+			}
+		}
+		q.pending = append(recovered, q.pending...)
+		q.inflight = make(map[string]Task)
+	}
+	// Everything the log still holds is pending again; quarantine then moves
+	// the poisoned ones on.
+	for _, t := range q.pending {
+		q.restoreState(t.ID, StatePending)
+	}
+	q.quarantine()
+	for _, t := range q.pending {
+		q.trackDeps(t)
+	}
+
+	q.wal = &wal{path: path, policy: policy, f: f}
+	if err := q.Compact(); err != nil {
+		q.wal.close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// replay applies every record in r and returns the byte offset just past the
+// last one, plus the IDs of replayed dequeues in log order. Only the final
+// record may be malformed, as left by a crash mid-write; it is skipped (and
+// truncated by the caller). A malformed record followed by others means the
+// log is damaged and fails with ErrCorruptWAL rather than dropping them.
+func (q *MemoryQueue) replay(r io.Reader) (int64, []string, error) {
+	br := bufio.NewReader(r)
+	var off int64
+	var order []string
+	for {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A final line without a newline was never fully written.
+			return off, order, nil
+		}
+		if err != nil {
+			return off, nil, err
+		}
+		var rec walRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			if _, perr := br.Peek(1); errors.Is(perr, io.EOF) {
+				return off, order, nil // torn final record
+			}
+			return off, nil, fmt.Errorf("%w: record at offset %d: %v", ErrCorruptWAL, off, err)
+		}
+		if rec.Task != nil && len(rec.Payload) > 0 {
+			if rec.Task.Payload, err = DecodeTyped(rec.Payload); err != nil {
+				return off, nil, err
+			}
+		}
+		if q.apply(rec) {
+			order = append(order, rec.ID)
+		}
+		off += int64(len(line))
+	}
+}
+
+// apply mutates in-memory state for one replayed record and reports whether
+// it moved a task in-flight.
+func (q *MemoryQueue) apply(rec walRecord) bool {
+	switch rec.Op {
+	case opEnqueue:
+		if rec.Task != nil {
+			q.pending = append(q.pending, *rec.Task)
+			q.sequence(rec.Task.ID)
+			if rec.Deliveries > 0 {
+				q.deliveries[rec.Task.ID] = rec.Deliveries
+			}
+		}
+	case opDequeue:
+		for i, t := range q.pending {
+			if t.ID == rec.ID {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				q.inflight[t.ID] = t
+				q.deliveries[t.ID]++
+				return true
+			}
+		}
+	case opAck:
+		delete(q.inflight, rec.ID)
+		delete(q.deliveries, rec.ID)
+		delete(q.seq, rec.ID)
+	case opDrop:
+		for i, t := range q.pending {
+			if t.ID == rec.ID {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				delete(q.deliveries, rec.ID)
+				delete(q.seq, rec.ID)
+				break
+			}
+		}
+	}
+	return false
+}
+
+// log appends rec to the WAL, if one is configured. Caller must hold q.mu.
+func (q *MemoryQueue) log(rec walRecord) error {
+	if q.wal == nil {
+		return nil
+	}
+	return q.wal.append(rec)
+}
+
+// maybeCompact compacts once enough acks have accumulated. Caller must hold q.mu.
+func (q *MemoryQueue) maybeCompact() error {
+	if q.wal == nil {
+		return nil
+	}
+	q.wal.acked++
+	if q.wal.acked < walCompactAfter {
+		return nil
+	}
+	return q.compactLocked()
+}
+
+// Compact rewrites the WAL so it only contains live (pending and in-flight)
+// tasks, dropping everything that has been acked.
+func (q *MemoryQueue) Compact() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.compactLocked()
+}
+
+func (q *MemoryQueue) compactLocked() error {
+	w := q.wal
+	if w == nil {
+		return nil
+	}
+	tmp := w.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	enc := json.NewEncoder(bw)
+	write := func(rec walRecord) {
+		if err == nil {
+			err = enc.Encode(rec)
+		}
+	}
+	writeTask := func(t Task, deliveries int) {
+		if err == nil {
+			var rec walRecord
+			if rec, err = q.enqueueRecord(t); err == nil {
+				rec.Deliveries = deliveries
+				write(rec)
+			}
+		}
+	}
+	for _, t := range q.inflightTasks() {
+		writeTask(t, q.deliveries[t.ID]-1) // the dequeue record adds the current one
+		write(walRecord{Op: opDequeue, ID: t.ID})
+	}
+	for _, t := range q.pending {
+		writeTask(t, q.deliveries[t.ID])
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		return err
+	}
+	if w.policy != SyncNever {
+		// The rename is only durable once the directory entry is.
+		if err := syncDir(filepath.Dir(w.path)); err != nil {
+			return err
+		}
+	}
+
+	nf, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.f.Close()
+	w.f = nf
+	w.unsynced = 0
+	w.acked = 0
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (w *wal) append(rec walRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := w.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	w.unsynced++
+	switch {
+	case w.policy == SyncAlways,
+		w.policy == SyncBatch && w.unsynced >= walBatchSize:
+		w.unsynced = 0
+		return w.f.Sync()
+	}
+	return nil
+}
+
+func (w *wal) close() error {
+	if w.policy != SyncNever {
+		if err := w.f.Sync(); err != nil {
+			w.f.Close()
+			return err
+		}
+	}
+	return w.f.Close()
+}